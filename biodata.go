@@ -14,7 +14,7 @@ func newModel() i.Model {
 	return i.Model{
 		Pages: map[int]i.Page{
 			// type, sub-type
-			0: i.NewChoicePage("Data", "What type of biological data?", []string{"DNA", "RNA", "Protein", "Literature"}, []int{1, 2, 3, 4}),
+			0: i.NewChoicePage("Data", "What type of biological data?", []string{"DNA", "RNA", "Protein", "Literature", "Local file", "My library", "Alignment"}, []int{1, 2, 3, 4, i.LocalFilePage, i.LibraryPage, i.AlignPage}),
 			1: i.NewChoicePage("DNA", "What sort of DNA data?", []string{"Genome", "Genes", "Variation"}, []int{5, 6, 7}),
 			2: i.NewChoicePage("RNA", "What sort of RNA data?", []string{"Transcript", "Expression"}, []int{8}),
 			3: i.NewChoicePage("Protein", "What sort of protein data?", []string{"Sequence", "Structure", "Interactions"}, []int{9, 10, 11}),
@@ -24,9 +24,19 @@ func newModel() i.Model {
 			5: i.NewChoicePage("Genomic", "Here are some genomic databases. Choose one to see what type of queries you can make.",
 				[]string{"GenBank", "RefSeq", "Ensembl", "UCSC Genome Browser"}, []int{14, 15, 16, 17}),
 
+			// local data
+			7: i.NewVariationPage(),
+
 			// access
 			14: i.NewEntrezPage("GenBank", "genbank", "GenBank is an archival NCBI dataset, containing all publicly submitted DNA sequences from individual labs and large-scale sequencing projects."),
 			15: i.NewEntrezPage("RefSeq", "refseq", "RefSeq is a manually curated NCBI datasetm, aiming to provide separate and linked records for the genomic DNA, the gene transcripts, and the proteins arising from those transcripts."),
+
+			// always reachable
+			i.DownloadsPage: i.NewDownloadsPage(),
+			i.CartPage:      i.NewCartPage(),
+			i.LocalFilePage: i.NewLocalFilePage(),
+			i.LibraryPage:   i.NewLibraryPage(),
+			i.AlignPage:     i.NewAlignPage(),
 		},
 		PreviousPages: []int{},
 		PreviousNames: []string{},
@@ -35,10 +45,22 @@ func newModel() i.Model {
 		Keys:          i.Keys,
 		ShowHelp:      true,
 		Help:          help.New(),
+		Downloads:     i.NewDownloadManager(),
+		Cart:          i.NewCart(),
+		Library:       i.NewLibrary(),
 	}
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		cmd, ok := commands[os.Args[1]]
+		if !ok {
+			usage()
+			os.Exit(2)
+		}
+		os.Exit(cmd(os.Args[2:]))
+	}
+
 	if len(os.Getenv("DEBUG")) > 0 {
 		f, err := tea.LogToFile("debug.log", "debug")
 		if err != nil {
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	i "github.com/zongleon/biodata/internal"
+)
+
+// non-interactive subcommands, run instead of the TUI
+var commands = map[string]func(args []string) int{
+	"verify":  verifyCommand,
+	"faidx":   faidxCommand,
+	"convert": convertCommand,
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: biodata [command]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "with no command, biodata starts the interactive browser.")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  verify [dir]    check downloads in dir against their provenance sidecars")
+	fmt.Fprintln(os.Stderr, "  faidx <fasta> [region ...]")
+	fmt.Fprintln(os.Stderr, "                  index a FASTA file (.fai) and print regions like chr1:100-200")
+	fmt.Fprintln(os.Stderr, "  convert [-f] [-from format] -to format <in> [out]")
+	fmt.Fprintln(os.Stderr, "                  convert between genbank, embl, fasta, gff3, json and xml")
+}
+
+func verifyCommand(args []string) int {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	results, err := i.Verify(dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "verify:", err)
+		return 1
+	}
+	if len(results) == 0 {
+		fmt.Printf("no provenance sidecars found in %s\n", dir)
+		return 0
+	}
+
+	failed := 0
+	for _, res := range results {
+		if res.OK {
+			fmt.Printf("OK      %s\n", res.Path)
+		} else {
+			failed++
+			fmt.Printf("FAILED  %s: %s\n", res.Path, res.Err)
+		}
+	}
+	fmt.Printf("\n%d checked, %d failed\n", len(results), failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func faidxCommand(args []string) int {
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+
+	idx, err := i.OpenFastaIndex(args[0])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "faidx:", err)
+		return 1
+	}
+	var recs []i.FastaRecord
+	for _, arg := range args[1:] {
+		r, err := i.ParseRegion(arg)
+		if err == nil {
+			var rec i.FastaRecord
+			rec, err = idx.FetchRecord(r)
+			recs = append(recs, rec)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "faidx:", err)
+			return 1
+		}
+	}
+	if err := i.WriteFasta(os.Stdout, recs); err != nil {
+		fmt.Fprintln(os.Stderr, "faidx:", err)
+		return 1
+	}
+	return 0
+}
+
+func convertCommand(args []string) int {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	from := fs.String("from", "", "input format, detected from the content if not given")
+	to := fs.String("to", "", "output format, taken from the output file's extension if not given")
+	force := fs.Bool("f", false, "overwrite the output file if it exists")
+	// allow flags after the file names too
+	var files []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return 2
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		files = append(files, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if len(files) == 0 || len(files) > 2 {
+		usage()
+		return 2
+	}
+	if *to == "" && len(files) == 2 {
+		*to = filepath.Ext(files[1])
+	}
+	if *to == "" {
+		fmt.Fprintln(os.Stderr, "convert: -to is required when writing to stdout")
+		return 2
+	}
+
+	// "-" reads stdin, and with no output file the result goes to stdout
+	if len(files) == 2 {
+		format, n, err := i.ConvertFile(files[0], files[1], *from, *to, *force)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "convert:", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "converted %d records from %s\n", n, format)
+		return 0
+	}
+
+	var err error
+	in := io.ReadCloser(os.Stdin)
+	if files[0] != "-" {
+		if in, err = i.OpenFile(files[0]); err != nil {
+			fmt.Fprintln(os.Stderr, "convert:", err)
+			return 1
+		}
+		defer in.Close()
+	}
+	if _, _, err := i.Convert(in, os.Stdout, *from, *to); err != nil {
+		fmt.Fprintln(os.Stderr, "convert:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,194 @@
+package internal
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// gap penalties to step through, open and extend
+var alignGaps = [][2]int{{10, 1}, {11, 1}, {5, 2}, {15, 2}, {8, 4}}
+
+type alignPage struct {
+	Title    string
+	Records  []GBSeq // the two picked, oldest first
+	Mode     AlignMode
+	Scoring  Scoring
+	Gap      int // index into alignGaps, -1 for the scoring's defaults
+	Result   *Alignment
+	Viewport viewport.Model
+	Aligning bool
+	run      int // counts alignments started, so stale results are dropped
+	Err      error
+}
+
+func NewAlignPage() *alignPage {
+	return &alignPage{
+		Title:    "Pairwise alignment",
+		Scoring:  Scorings[0],
+		Gap:      -1,
+		Viewport: viewport.New(0, 0),
+	}
+}
+
+// pick adds a record, replacing the older one once there are two, and
+// matches the scoring to the molecule.
+func (page *alignPage) pick(rec GBSeq) {
+	page.Records = append(page.Records, rec)
+	if len(page.Records) > 2 {
+		page.Records = page.Records[1:]
+	}
+	if protein := IsProtein(rec); protein != page.Scoring.Protein {
+		page.Scoring = Scorings[0]
+		if protein {
+			page.Scoring = Scorings[1]
+		}
+		page.Gap = -1
+	}
+	page.Result, page.Err = nil, nil
+	page.Aligning = false
+	page.run++
+}
+
+func (page *alignPage) names() (string, string) {
+	return CartItemFromSeq("nuccore", page.Records[0]).Accession, CartItemFromSeq("nuccore", page.Records[1]).Accession
+}
+
+// scoring is the chosen scoring with the chosen gap penalties.
+func (page *alignPage) scoring() Scoring {
+	sc := page.Scoring
+	if page.Gap >= 0 {
+		sc.Open, sc.Extend = alignGaps[page.Gap][0], alignGaps[page.Gap][1]
+	}
+	return sc
+}
+
+type alignedMsg struct {
+	run    int
+	result *Alignment
+	err    error
+}
+
+// align reruns the alignment with the current settings in the background.
+func (page *alignPage) align() tea.Cmd {
+	page.Result, page.Err = nil, nil
+	page.Aligning = false
+	page.run++
+	if len(page.Records) < 2 {
+		return nil
+	}
+	a, b := page.Records[0], page.Records[1]
+	if IsProtein(a) != IsProtein(b) {
+		page.Err = fmt.Errorf("can't align a protein against a nucleotide sequence")
+		return nil
+	}
+	page.Aligning = true
+	run, mode, sc := page.run, page.Mode, page.scoring()
+	return func() tea.Msg {
+		aln, err := Align(a.Sequence, b.Sequence, mode, sc)
+		if err != nil {
+			return alignedMsg{run: run, err: err}
+		}
+		return alignedMsg{run: run, result: &aln}
+	}
+}
+
+// render lays the result out to fit the window.
+func (page *alignPage) render(m Model) {
+	if page.Result == nil {
+		return
+	}
+	nameA, nameB := page.names()
+	page.Viewport.Width, page.Viewport.Height = max(40, m.Width-20), max(5, m.Height-16)
+	page.Viewport.SetContent(AlignmentStats(*page.Result) + "\n" + AlignmentView(*page.Result, nameA, nameB, page.scoring(), page.Viewport.Width))
+}
+
+// UpdatePage implements page.
+func (page *alignPage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.Keys.Back):
+			m.UpdateBack(msg)
+			return m, nil
+		case msg.String() == "m":
+			page.Mode = (page.Mode + 1) % (SemiGlobalAlignment + 1)
+			return m, page.align()
+		case msg.String() == "s":
+			for idx, sc := range Scorings {
+				if sc.Name == page.Scoring.Name {
+					page.Scoring = Scorings[(idx+1)%len(Scorings)]
+					break
+				}
+			}
+			page.Gap = -1
+			return m, page.align()
+		case msg.String() == "g":
+			page.Gap++
+			if page.Gap >= len(alignGaps) {
+				page.Gap = -1
+			}
+			return m, page.align()
+		case msg.String() == "x" && len(page.Records) == 2:
+			page.Records[0], page.Records[1] = page.Records[1], page.Records[0]
+			return m, page.align()
+		case key.Matches(msg, m.Keys.Enter):
+			return m, page.align()
+		case msg.String() == "D" && len(page.Records) == 2:
+			dp := NewDotPlotPage(page.Records[0], page.Records[1], m.Width-20, m.Height-8)
+			m.Pages[DotPlotPage] = dp
+			m.UpdateHistory(m.Page, page.Title)
+			m.Page = DotPlotPage
+			return m, dp.Start()
+		}
+
+	case alignedMsg:
+		if msg.run != page.run {
+			return m, nil
+		}
+		page.Aligning = false
+		page.Result, page.Err = msg.result, msg.err
+		page.render(m)
+		page.Viewport.GotoTop()
+		return m, nil
+
+	case tea.WindowSizeMsg:
+		page.render(m)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	page.Viewport, cmd = page.Viewport.Update(msg)
+	return m, cmd
+}
+
+// Page implements page.
+func (page *alignPage) Page(m Model) string {
+	p := ""
+	for idx, rec := range page.Records {
+		p += fmt.Sprintf("%c: %s  %s, %d %s\n", "AB"[idx], CartItemFromSeq("nuccore", rec).Accession, rec.Definition, len(rec.Sequence), lengthUnit(rec))
+	}
+	if len(page.Records) < 2 {
+		p += faintStyle.Render("press ctrl-p on a record, a search result or a library entry to pick it for alignment") + "\n\n"
+		return p
+	}
+
+	sc := page.scoring()
+	p += fmt.Sprintf("%s, %s, gap open %d, extend %d\n", page.Mode, sc.Name, sc.Open, sc.Extend)
+	p += faintStyle.Render("m: mode • s: scoring • g: gap penalties • x: swap A and B • D: dot plot • ↑/↓: scroll") + "\n\n"
+	switch {
+	case page.Err != nil:
+		p += errorStyle.Render(page.Err.Error()) + "\n"
+	case page.Aligning:
+		p += faintStyle.Render("aligning ...") + "\n"
+	case page.Result != nil:
+		p += page.Viewport.View() + "\n"
+	}
+	return p + "\n"
+}
+
+func (page *alignPage) GetTitle() string {
+	return page.Title
+}
@@ -0,0 +1,418 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+type AlignMode int
+
+const (
+	GlobalAlignment     AlignMode = iota // Needleman-Wunsch
+	LocalAlignment                       // Smith-Waterman
+	SemiGlobalAlignment                  // end gaps are free
+)
+
+func (m AlignMode) String() string {
+	return [...]string{"global (Needleman-Wunsch)", "local (Smith-Waterman)", "semi-global (free end gaps)"}[m]
+}
+
+// Scoring is a substitution matrix with its default gap penalties. A gap of
+// length k costs Open + (k-1)*Extend.
+type Scoring struct {
+	Name         string
+	Protein      bool
+	Open, Extend int
+	matrix       *[256][256]int8
+}
+
+func (s Scoring) Score(a, b byte) int { return int(s.matrix[a][b]) }
+
+func (s Scoring) String() string {
+	return fmt.Sprintf("%s, gap open %d, extend %d", s.Name, s.Open, s.Extend)
+}
+
+var Scorings = []Scoring{
+	{Name: "DNA (match 5, mismatch -4)", Open: 10, Extend: 1, matrix: dnaMatrix()},
+	{Name: "BLOSUM62", Protein: true, Open: 11, Extend: 1, matrix: parseMatrix(blosum62)},
+	{Name: "PAM250", Protein: true, Open: 10, Extend: 1, matrix: parseMatrix(pam250)},
+}
+
+// dnaMatrix scores identical bases 5 and anything else -4, U matching T.
+func dnaMatrix() *[256][256]int8 {
+	var m [256][256]int8
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			ua, ub := upper(byte(a)), upper(byte(b))
+			if ua == 'U' {
+				ua = 'T'
+			}
+			if ub == 'U' {
+				ub = 'T'
+			}
+			m[a][b] = -4
+			if ua == ub && ua >= 'A' && ua <= 'Z' && ua != 'N' {
+				m[a][b] = 5
+			}
+		}
+	}
+	return &m
+}
+
+// parseMatrix reads a matrix in NCBI's layout. Letters not in the matrix
+// score as X, in either case.
+func parseMatrix(text string) *[256][256]int8 {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	cols := strings.Fields(lines[0])
+	scores := map[[2]byte]int8{}
+	for _, line := range lines[1:] {
+		f := strings.Fields(line)
+		for j, v := range f[1:] {
+			var n int
+			fmt.Sscan(v, &n)
+			scores[[2]byte{f[0][0], cols[j][0]}] = int8(n)
+		}
+	}
+	known := func(c byte) byte {
+		c = upper(c)
+		if _, ok := scores[[2]byte{c, c}]; ok {
+			return c
+		}
+		return 'X'
+	}
+	var m [256][256]int8
+	for a := 0; a < 256; a++ {
+		for b := 0; b < 256; b++ {
+			m[a][b] = scores[[2]byte{known(byte(a)), known(byte(b))}]
+		}
+	}
+	return &m
+}
+
+// AlignCellLimit caps the size of the dynamic programming matrix, the
+// product of the two lengths. The traceback takes a byte per cell.
+var AlignCellLimit = 25_000_000
+
+type Alignment struct {
+	A, B       string // aligned rows, gaps as -
+	Score      int
+	StartA     int // 1-based first aligned residue of each sequence
+	StartB     int
+	Length     int
+	Identities int
+	Similar    int // pairs with a positive score, identities included
+	Gaps       int
+}
+
+// traceback bits per cell: which state M came from, and whether X and Y
+// extended a gap or opened one
+const (
+	fromM = iota
+	fromX
+	fromY
+	fromStart
+	xExtends = 4
+	yExtends = 8
+)
+
+// Align aligns b against a with affine gap penalties (Gotoh's algorithm).
+// Semi-global and global alignments include the unaligned ends as gaps;
+// local alignments are just the aligned part.
+func Align(a, b string, mode AlignMode, sc Scoring) (Alignment, error) {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return Alignment{}, fmt.Errorf("nothing to align")
+	}
+	if n*m > AlignCellLimit {
+		return Alignment{}, fmt.Errorf("%d x %d is too big to align, the limit is %d cells", n, m, AlignCellLimit)
+	}
+	neg := math.MinInt32 / 2
+	open, ext := sc.Open, sc.Extend
+	free := mode != GlobalAlignment
+
+	// scores of the previous and current rows for the three states: M ends
+	// in a pair, X in a gap in b, Y in a gap in a
+	pM, pX, pY := make([]int, m+1), make([]int, m+1), make([]int, m+1)
+	cM, cX, cY := make([]int, m+1), make([]int, m+1), make([]int, m+1)
+	tb := make([]byte, (n+1)*(m+1))
+
+	for j := 1; j <= m; j++ {
+		pM[j], pX[j], pY[j] = neg, neg, -(open + (j-1)*ext)
+		if j > 1 {
+			tb[j] = yExtends
+		}
+		if free {
+			pM[j], pY[j] = 0, neg
+		}
+	}
+	pX[0], pY[0] = neg, neg
+
+	best, bi, bj, bs := neg, 0, 0, fromM
+	for i := 1; i <= n; i++ {
+		row := i * (m + 1)
+		cM[0], cX[0], cY[0] = neg, -(open + (i-1)*ext), neg
+		if i > 1 {
+			tb[row] = xExtends
+		}
+		if free {
+			cM[0], cX[0] = 0, neg
+		}
+		for j := 1; j <= m; j++ {
+			var t byte
+			diag, from := pM[j-1], byte(fromM)
+			if pX[j-1] > diag {
+				diag, from = pX[j-1], fromX
+			}
+			if pY[j-1] > diag {
+				diag, from = pY[j-1], fromY
+			}
+			if mode == LocalAlignment && diag < 0 {
+				diag, from = 0, fromStart
+			}
+			cM[j] = diag + sc.Score(a[i-1], b[j-1])
+			t = from
+
+			cX[j] = pM[j] - open
+			if e := pX[j] - ext; e > cX[j] {
+				cX[j] = e
+				t |= xExtends
+			}
+			cY[j] = cM[j-1] - open
+			if e := cY[j-1] - ext; e > cY[j] {
+				cY[j] = e
+				t |= yExtends
+			}
+			tb[row+j] = t
+
+			if mode == LocalAlignment && cM[j] > best {
+				best, bi, bj, bs = cM[j], i, j, fromM
+			}
+		}
+		if mode == SemiGlobalAlignment && i < n {
+			// ending anywhere in the last column
+			for s, v := range []int{cM[m], cX[m], cY[m]} {
+				if v > best {
+					best, bi, bj, bs = v, i, m, s
+				}
+			}
+		}
+		pM, cM = cM, pM
+		pX, cX = cX, pX
+		pY, cY = cY, pY
+	}
+
+	// the last row is in p* now
+	switch mode {
+	case GlobalAlignment:
+		best, bi, bj, bs = pM[m], n, m, fromM
+		if pX[m] > best {
+			best, bs = pX[m], fromX
+		}
+		if pY[m] > best {
+			best, bs = pY[m], fromY
+		}
+	case SemiGlobalAlignment:
+		for j := 1; j <= m; j++ {
+			for s, v := range []int{pM[j], pX[j], pY[j]} {
+				if v > best {
+					best, bi, bj, bs = v, n, j, s
+				}
+			}
+		}
+	}
+
+	// trace back from the best cell
+	var ra, rb []byte
+	i, j, state := bi, bj, bs
+	for i > 0 || j > 0 {
+		if free && (i == 0 || j == 0) {
+			break
+		}
+		t := tb[i*(m+1)+j]
+		switch state {
+		case fromM:
+			ra, rb = append(ra, a[i-1]), append(rb, b[j-1])
+			i, j = i-1, j-1
+			state = int(t & 3)
+		case fromX:
+			ra, rb = append(ra, a[i-1]), append(rb, '-')
+			i--
+			state = fromM
+			if t&xExtends != 0 {
+				state = fromX
+			}
+		case fromY:
+			ra, rb = append(ra, '-'), append(rb, b[j-1])
+			j--
+			state = fromM
+			if t&yExtends != 0 {
+				state = fromY
+			}
+		}
+		if state == fromStart {
+			break
+		}
+	}
+	reverseBytes(ra)
+	reverseBytes(rb)
+
+	aln := Alignment{Score: best, StartA: i + 1, StartB: j + 1}
+	if mode == SemiGlobalAlignment {
+		// show the overhanging ends as gaps
+		lead := max(i, j)
+		ra = append([]byte(strings.Repeat("-", lead-i)+a[:i]), ra...)
+		rb = append([]byte(strings.Repeat("-", lead-j)+b[:j]), rb...)
+		trail := max(n-bi, m-bj)
+		ra = append(ra, a[bi:]+strings.Repeat("-", trail-(n-bi))...)
+		rb = append(rb, b[bj:]+strings.Repeat("-", trail-(m-bj))...)
+		aln.StartA, aln.StartB = 1, 1
+	}
+	aln.A, aln.B = string(ra), string(rb)
+	aln.Length = len(ra)
+	for k := range ra {
+		switch {
+		case ra[k] == '-' || rb[k] == '-':
+			aln.Gaps++
+		case upper(ra[k]) == upper(rb[k]):
+			aln.Identities++
+			aln.Similar++
+		case sc.Score(ra[k], rb[k]) > 0:
+			aln.Similar++
+		}
+	}
+	return aln, nil
+}
+
+func reverseBytes(s []byte) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+var (
+	mismatchStyle = errorStyle
+	similarStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+)
+
+// AlignmentView lays out the alignment in blocks, each row numbered by
+// residue, with | for identities and : for similar pairs between the rows.
+// Identities are green, similar pairs yellow, mismatches red.
+func AlignmentView(aln Alignment, nameA, nameB string, sc Scoring, width int) string {
+	block := 60
+	if width < 90 {
+		block = 40
+	}
+	nameA, nameB = truncateName(nameA, 15), truncateName(nameB, 15)
+
+	var sb strings.Builder
+	posA, posB := aln.StartA, aln.StartB
+	for from := 0; from < aln.Length; from += block {
+		to := min(from+block, aln.Length)
+		var la, mid, lb strings.Builder
+		endA, endB := posA-1, posB-1
+		for k := from; k < to; k++ {
+			ca, cb := aln.A[k], aln.B[k]
+			if ca != '-' {
+				endA++
+			}
+			if cb != '-' {
+				endB++
+			}
+			style, m := mismatchStyle, byte(' ')
+			switch {
+			case ca == '-' || cb == '-':
+				style = faintStyle
+			case upper(ca) == upper(cb):
+				style, m = doneStyle, '|'
+			case sc.Score(ca, cb) > 0:
+				style, m = similarStyle, ':'
+			}
+			la.WriteString(style.Render(string(ca)))
+			lb.WriteString(style.Render(string(cb)))
+			mid.WriteByte(m)
+		}
+		sb.WriteString(fmt.Sprintf("%-15s %9d %s %d\n", nameA, posA, la.String(), endA))
+		sb.WriteString(fmt.Sprintf("%-15s %9s %s\n", "", "", mid.String()))
+		sb.WriteString(fmt.Sprintf("%-15s %9d %s %d\n\n", nameB, posB, lb.String(), endB))
+		posA, posB = endA+1, endB+1
+	}
+	return sb.String()
+}
+
+func truncateName(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-1] + "…"
+}
+
+// AlignmentStats summarizes an alignment EMBOSS style.
+func AlignmentStats(aln Alignment) string {
+	var sb strings.Builder
+	padding := fmt.Sprintf("%%-%ds", LabelPadding)
+	sb.WriteString(fmt.Sprintf(padding+" %d\n", "Length:", aln.Length))
+	sb.WriteString(fmt.Sprintf(padding+" %d/%d (%.1f%%)\n", "Identity:", aln.Identities, aln.Length, percent(aln.Identities, aln.Length)))
+	sb.WriteString(fmt.Sprintf(padding+" %d/%d (%.1f%%)\n", "Similarity:", aln.Similar, aln.Length, percent(aln.Similar, aln.Length)))
+	sb.WriteString(fmt.Sprintf(padding+" %d/%d (%.1f%%)\n", "Gaps:", aln.Gaps, aln.Length, percent(aln.Gaps, aln.Length)))
+	sb.WriteString(fmt.Sprintf(padding+" %d\n", "Score:", aln.Score))
+	return sb.String()
+}
+
+const blosum62 = `
+   A  R  N  D  C  Q  E  G  H  I  L  K  M  F  P  S  T  W  Y  V  B  Z  X  *
+A  4 -1 -2 -2  0 -1 -1  0 -2 -1 -1 -1 -1 -2 -1  1  0 -3 -2  0 -2 -1  0 -4
+R -1  5  0 -2 -3  1  0 -2  0 -3 -2  2 -1 -3 -2 -1 -1 -3 -2 -3 -1  0 -1 -4
+N -2  0  6  1 -3  0  0  0  1 -3 -3  0 -2 -3 -2  1  0 -4 -2 -3  3  0 -1 -4
+D -2 -2  1  6 -3  0  2 -1 -1 -3 -4 -1 -3 -3 -1  0 -1 -4 -3 -3  4  1 -1 -4
+C  0 -3 -3 -3  9 -3 -4 -3 -3 -1 -1 -3 -1 -2 -3 -1 -1 -2 -2 -1 -3 -3 -2 -4
+Q -1  1  0  0 -3  5  2 -2  0 -3 -2  1  0 -3 -1  0 -1 -2 -1 -2  0  3 -1 -4
+E -1  0  0  2 -4  2  5 -2  0 -3 -3  1 -2 -3 -1  0 -1 -3 -2 -2  1  4 -1 -4
+G  0 -2  0 -1 -3 -2 -2  6 -2 -4 -4 -2 -3 -3 -2  0 -2 -2 -3 -3 -1 -2 -1 -4
+H -2  0  1 -1 -3  0  0 -2  8 -3 -3 -1 -2 -1 -2 -1 -2 -2  2 -3  0  0 -1 -4
+I -1 -3 -3 -3 -1 -3 -3 -4 -3  4  2 -3  1  0 -3 -2 -1 -3 -1  3 -3 -3 -1 -4
+L -1 -2 -3 -4 -1 -2 -3 -4 -3  2  4 -2  2  0 -3 -2 -1 -2 -1  1 -4 -3 -1 -4
+K -1  2  0 -1 -3  1  1 -2 -1 -3 -2  5 -1 -3 -1  0 -1 -3 -2 -2  0  1 -1 -4
+M -1 -1 -2 -3 -1  0 -2 -3 -2  1  2 -1  5  0 -2 -1 -1 -1 -1  1 -3 -1 -1 -4
+F -2 -3 -3 -3 -2 -3 -3 -3 -1  0  0 -3  0  6 -4 -2 -2  1  3 -1 -3 -3 -1 -4
+P -1 -2 -2 -1 -3 -1 -1 -2 -2 -3 -3 -1 -2 -4  7 -1 -1 -4 -3 -2 -2 -1 -2 -4
+S  1 -1  1  0 -1  0  0  0 -1 -2 -2  0 -1 -2 -1  4  1 -3 -2 -2  0  0  0 -4
+T  0 -1  0 -1 -1 -1 -1 -2 -2 -1 -1 -1 -1 -2 -1  1  5 -2 -2  0 -1 -1  0 -4
+W -3 -3 -4 -4 -2 -2 -3 -2 -2 -3 -2 -3 -1  1 -4 -3 -2 11  2 -3 -4 -3 -2 -4
+Y -2 -2 -2 -3 -2 -1 -2 -3  2 -1 -1 -2 -1  3 -3 -2 -2  2  7 -1 -3 -2 -1 -4
+V  0 -3 -3 -3 -1 -2 -2 -3 -3  3  1 -2  1 -1 -2 -2  0 -3 -1  4 -3 -2 -1 -4
+B -2 -1  3  4 -3  0  1 -1  0 -3 -4  0 -3 -3 -2  0 -1 -4 -3 -3  4  1 -1 -4
+Z -1  0  0  1 -3  3  4 -2  0 -3 -3  1 -1 -3 -1  0 -1 -3 -2 -2  1  4 -1 -4
+X  0 -1 -1 -1 -2 -1 -1 -1 -1 -1 -1 -1 -1 -1 -2  0  0 -2 -1 -1 -1 -1 -1 -4
+* -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4 -4  1
+`
+
+const pam250 = `
+   A  R  N  D  C  Q  E  G  H  I  L  K  M  F  P  S  T  W  Y  V  B  Z  X  *
+A  2 -2  0  0 -2  0  0  1 -1 -1 -2 -1 -1 -3  1  1  1 -6 -3  0  0  0  0 -8
+R -2  6  0 -1 -4  1 -1 -3  2 -2 -3  3  0 -4  0  0 -1  2 -4 -2 -1  0 -1 -8
+N  0  0  2  2 -4  1  1  0  2 -2 -3  1 -2 -3  0  1  0 -4 -2 -2  2  1  0 -8
+D  0 -1  2  4 -5  2  3  1  1 -2 -4  0 -3 -6 -1  0  0 -7 -4 -2  3  3 -1 -8
+C -2 -4 -4 -5 12 -5 -5 -3 -3 -2 -6 -5 -5 -4 -3  0 -2 -8  0 -2 -4 -5 -3 -8
+Q  0  1  1  2 -5  4  2 -1  3 -2 -2  1 -1 -5  0 -1 -1 -5 -4 -2  1  3 -1 -8
+E  0 -1  1  3 -5  2  4  0  1 -2 -3  0 -2 -5 -1  0  0 -7 -4 -2  3  3 -1 -8
+G  1 -3  0  1 -3 -1  0  5 -2 -3 -4 -2 -3 -5  0  1  0 -7 -5 -1  0  0 -1 -8
+H -1  2  2  1 -3  3  1 -2  6 -2 -2  0 -2 -2  0 -1 -1 -3  0 -2  1  2 -1 -8
+I -1 -2 -2 -2 -2 -2 -2 -3 -2  5  2 -2  2  1 -2 -1  0 -5 -1  4 -2 -2 -1 -8
+L -2 -3 -3 -4 -6 -2 -3 -4 -2  2  6 -3  4  2 -3 -3 -2 -2 -1  2 -3 -3 -1 -8
+K -1  3  1  0 -5  1  0 -2  0 -2 -3  5  0 -5 -1  0  0 -3 -4 -2  1  0 -1 -8
+M -1  0 -2 -3 -5 -1 -2 -3 -2  2  4  0  6  0 -2 -2 -1 -4 -2  2 -2 -2 -1 -8
+F -3 -4 -3 -6 -4 -5 -5 -5 -2  1  2 -5  0  9 -5 -3 -3  0  7 -1 -4 -5 -2 -8
+P  1  0  0 -1 -3  0 -1  0  0 -2 -3 -1 -2 -5  6  1  0 -6 -5 -1 -1  0 -1 -8
+S  1  0  1  0  0 -1  0  1 -1 -1 -3  0 -2 -3  1  2  1 -2 -3 -1  0  0  0 -8
+T  1 -1  0  0 -2 -1  0  0 -1  0 -2  0 -1 -3  0  1  3 -5 -3  0  0 -1  0 -8
+W -6  2 -4 -7 -8 -5 -7 -7 -3 -5 -2 -3 -4  0 -6 -2 -5 17  0 -6 -5 -6 -4 -8
+Y -3 -4 -2 -4  0 -4 -4 -5  0 -1 -1 -4 -2  7 -5 -3 -3  0 10 -2 -3 -4 -2 -8
+V  0 -2 -2 -2 -2 -2 -2 -1 -2  4  2 -2  2 -1 -1 -1  0 -6 -2  4 -2 -2 -1 -8
+B  0 -1  2  3 -4  1  3  0  1 -2 -3  1 -2 -4 -1  0  0 -5 -3 -2  3  2 -1 -8
+Z  0  0  1  3 -5  3  3  0  2 -2 -3  0 -2 -5  0  0 -1 -6 -4 -2  2  3 -1 -8
+X  0 -1  0 -1 -3 -1 -1 -1 -1 -1 -1 -1 -1 -2 -1  0  0 -4 -2 -1 -1 -1 -1 -8
+* -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8 -8  1
+`
@@ -0,0 +1,210 @@
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type bedItem struct {
+	rec      BEDRecord
+	bedGraph bool
+}
+
+func (i bedItem) Title() string {
+	return i.rec.Label()
+}
+
+func (i bedItem) Description() string {
+	d := fmt.Sprintf("%s:%d-%d, %d bp", i.rec.Chrom, i.rec.Start+1, i.rec.End, i.rec.End-i.rec.Start)
+	if i.bedGraph {
+		return d + fmt.Sprintf(" - %g", i.rec.Value)
+	}
+	if i.rec.Strand == "+" || i.rec.Strand == "-" {
+		d += " (" + i.rec.Strand + ")"
+	}
+	if n := len(i.rec.BlockSizes); n > 0 {
+		d += fmt.Sprintf(" - %d blocks", n)
+	}
+	return d
+}
+
+func (i bedItem) FilterValue() string { return i.rec.Label() + " " + i.rec.Chrom }
+
+type bedPage struct {
+	Title     string
+	Path      string
+	File      *BEDFile
+	Index     *IntervalIndex
+	Results   list.Model
+	Region    textinput.Model
+	Accession textinput.Model
+	Editing   *textinput.Model
+	Shown     []BEDRecord // intervals matching the current region
+	Status    string
+	Err       error
+}
+
+func NewBEDPage(path string, b *BEDFile, width, height int) *bedPage {
+	d := list.NewDefaultDelegate()
+	d.UpdateFunc = UpdateDelegate
+
+	region := textinput.New()
+	region.Prompt = "Region:    "
+	region.Placeholder = "chr1:10000-20000, empty for all"
+	region.Width = 30
+
+	acc := textinput.New()
+	acc.Prompt = "Accession: "
+	acc.Placeholder = "NC_000001.11, empty to use each chrom"
+	acc.Width = 30
+
+	page := &bedPage{
+		Title:     filepath.Base(path),
+		Path:      path,
+		File:      b,
+		Index:     NewIntervalIndex(b.Records),
+		Results:   list.New(nil, d, width, height-6),
+		Region:    region,
+		Accession: acc,
+	}
+	page.query()
+	return page
+}
+
+// query lists the intervals overlapping the region, or all of them. A bad
+// region leaves the current list alone, so x can't extract the whole file.
+func (page *bedPage) query() {
+	shown := page.File.Records
+	if v := strings.TrimSpace(page.Region.Value()); v != "" {
+		r, err := ParseRegion(v)
+		if err != nil {
+			page.Err = err
+			return
+		}
+		shown = page.Index.Query(r)
+	}
+	page.Err = nil
+	page.Shown = shown
+
+	items := make([]list.Item, len(page.Shown))
+	for idx, rec := range page.Shown {
+		items[idx] = bedItem{rec: rec, bedGraph: page.File.BedGraph}
+	}
+	page.Results.SetItems(items)
+	page.Results.ResetSelected()
+	page.Results.Title = fmt.Sprintf("%d of %d intervals", len(page.Shown), len(page.File.Records))
+}
+
+// extractJob builds a FASTA download with one record per listed interval.
+func (page *bedPage) extractJob() *DownloadJob {
+	acc := strings.TrimSpace(page.Accession.Value())
+	regions := make([]BEDRecord, len(page.Shown))
+	var ids []string
+	var estimate int64
+	for idx, rec := range page.Shown {
+		if acc != "" {
+			rec.Chrom = acc
+		}
+		regions[idx] = rec
+		if !slices.Contains(ids, rec.Chrom) {
+			ids = append(ids, rec.Chrom)
+		}
+		n := int64(rec.End - rec.Start)
+		estimate += n + n/60 + 80
+	}
+	name := strings.TrimSuffix(filepath.Base(page.Path), filepath.Ext(page.Path))
+	return &DownloadJob{
+		Database:  "nuccore",
+		Accession: name + "-regions-" + time.Now().Format("20060102-150405"),
+		Ids:       ids,
+		Format:    DownloadFormats[0],
+		Regions:   regions,
+		Estimate:  estimate,
+	}
+}
+
+// UpdatePage implements page.
+func (page *bedPage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if page.Editing != nil {
+			if msg.String() != "enter" {
+				var cmd tea.Cmd
+				*page.Editing, cmd = page.Editing.Update(msg)
+				return m, cmd
+			}
+			editing := page.Editing
+			editing.Blur()
+			page.Editing = nil
+			if editing == &page.Region {
+				page.query()
+				return m, nil
+			}
+			if len(page.Shown) == 0 {
+				page.Status = "no intervals to extract"
+				return m, nil
+			}
+			job := page.extractJob()
+			page.Status = fmt.Sprintf("extracting %d intervals (ctrl+o to view)", len(job.Regions))
+			return m, m.Downloads.Enqueue(job)
+		}
+
+		if page.Results.FilterState() == list.Filtering {
+			break
+		}
+		switch {
+		case msg.String() == "r":
+			page.Editing = &page.Region
+			return m, page.Region.Focus()
+		case msg.String() == "x" && !page.File.BedGraph:
+			page.Editing = &page.Accession
+			return m, page.Accession.Focus()
+		case key.Matches(msg, m.Keys.Back) && page.Results.FilterState() == list.Unfiltered:
+			m.UpdateBack(msg)
+			return m, nil
+		}
+
+	case tea.WindowSizeMsg:
+		page.Results.SetSize(msg.Width-20, msg.Height-14)
+	}
+
+	var cmd tea.Cmd
+	page.Results, cmd = page.Results.Update(msg)
+	return m, cmd
+}
+
+// Page implements page.
+func (page *bedPage) Page(m Model) string {
+	kind := "BED"
+	if page.File.BedGraph {
+		kind = "bedGraph"
+	}
+	p := fmt.Sprintf("%s file, %d intervals\n", kind, len(page.File.Records))
+	p += page.Region.View() + "\n"
+	help := "r: overlap query • /: filter by name"
+	if !page.File.BedGraph {
+		p += page.Accession.View() + "\n"
+		help += " • x: extract listed intervals as FASTA"
+	}
+	p += faintStyle.Render(help) + "\n\n"
+	p += page.Results.View()
+	if page.Status != "" {
+		p += "\n" + faintStyle.Render(page.Status)
+	}
+	if page.Err != nil {
+		p += "\n\n" + errorStyle.Render(page.Err.Error())
+	}
+	return p + "\n\n"
+}
+
+func (page *bedPage) GetTitle() string {
+	return page.Title
+}
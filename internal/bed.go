@@ -0,0 +1,210 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BEDRecord is one line of a BED3 to BED12 or bedGraph file. Start and End
+// are 0-based and half-open, as in the file.
+type BEDRecord struct {
+	Chrom       string
+	Start       int
+	End         int
+	Name        string
+	Score       string
+	Strand      string
+	ThickStart  int
+	ThickEnd    int
+	ItemRGB     string
+	BlockSizes  []int
+	BlockStarts []int
+	Value       float64 // bedGraph data value
+	Fields      int     // number of columns on the line
+}
+
+// Label names the interval for FASTA headers and lists.
+func (rec BEDRecord) Label() string {
+	if rec.Name != "" && rec.Name != "." {
+		return rec.Name
+	}
+	return fmt.Sprintf("%s:%d-%d", rec.Chrom, rec.Start+1, rec.End)
+}
+
+type BEDFile struct {
+	BedGraph bool
+	Tracks   []string
+	Records  []BEDRecord
+}
+
+// ParseBED reads BED or bedGraph. A "track type=bedGraph" line, or four
+// columns with a numeric fourth, mark bedGraph.
+func ParseBED(r io.Reader) (*BEDFile, error) {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 1<<16), 1<<24)
+
+	b := &BEDFile{}
+	line := 0
+	for s.Scan() {
+		line++
+		text := strings.TrimRight(s.Text(), "\r")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, "browser") {
+			continue
+		}
+		if strings.HasPrefix(text, "track") {
+			b.Tracks = append(b.Tracks, text)
+			if strings.Contains(text, "type=bedGraph") {
+				b.BedGraph = true
+			}
+			continue
+		}
+
+		cols := strings.Split(text, "\t")
+		if len(cols) < 3 {
+			cols = strings.Fields(text)
+		}
+		if len(cols) < 3 {
+			return nil, fmt.Errorf("line %d: expected at least 3 columns", line)
+		}
+		if len(b.Records) == 0 && len(cols) == 4 {
+			if _, err := strconv.ParseFloat(cols[3], 64); err == nil {
+				b.BedGraph = true
+			}
+		}
+		rec, err := parseBEDLine(cols, b.BedGraph)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		b.Records = append(b.Records, rec)
+	}
+	return b, s.Err()
+}
+
+func parseBEDLine(cols []string, bedGraph bool) (BEDRecord, error) {
+	rec := BEDRecord{Chrom: cols[0], Fields: len(cols)}
+	var err error
+	if rec.Start, err = strconv.Atoi(cols[1]); err != nil {
+		return rec, fmt.Errorf("bad start %q", cols[1])
+	}
+	if rec.End, err = strconv.Atoi(cols[2]); err != nil {
+		return rec, fmt.Errorf("bad end %q", cols[2])
+	}
+	if rec.End < rec.Start {
+		return rec, fmt.Errorf("end %d is before start %d", rec.End, rec.Start)
+	}
+	if bedGraph {
+		if len(cols) < 4 {
+			return rec, fmt.Errorf("bedGraph lines need 4 columns")
+		}
+		if rec.Value, err = strconv.ParseFloat(cols[3], 64); err != nil {
+			return rec, fmt.Errorf("bad value %q", cols[3])
+		}
+		return rec, nil
+	}
+
+	get := func(idx int) string {
+		if idx < len(cols) {
+			return cols[idx]
+		}
+		return ""
+	}
+	rec.Name = get(3)
+	rec.Score = get(4)
+	rec.Strand = get(5)
+	if len(cols) >= 8 {
+		rec.ThickStart, _ = strconv.Atoi(cols[6])
+		rec.ThickEnd, _ = strconv.Atoi(cols[7])
+	}
+	rec.ItemRGB = get(8)
+	if len(cols) >= 12 {
+		if rec.BlockSizes, err = parseIntList(cols[10]); err != nil {
+			return rec, fmt.Errorf("bad blockSizes: %v", err)
+		}
+		if rec.BlockStarts, err = parseIntList(cols[11]); err != nil {
+			return rec, fmt.Errorf("bad blockStarts: %v", err)
+		}
+		if count, _ := strconv.Atoi(cols[9]); count != len(rec.BlockSizes) || count != len(rec.BlockStarts) {
+			return rec, fmt.Errorf("blockCount %s doesn't match the block lists", cols[9])
+		}
+	}
+	return rec, nil
+}
+
+func parseIntList(s string) ([]int, error) {
+	var out []int
+	for _, v := range strings.Split(strings.TrimSuffix(s, ","), ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+// IntervalIndex answers overlap queries over BED records. Intervals are
+// sorted by start per chromosome; since none is longer than the longest one,
+// a query only has to look back that far from its own start.
+type IntervalIndex struct {
+	byChrom map[string][]BEDRecord
+	maxLen  map[string]int
+}
+
+func NewIntervalIndex(recs []BEDRecord) *IntervalIndex {
+	idx := &IntervalIndex{byChrom: map[string][]BEDRecord{}, maxLen: map[string]int{}}
+	for _, rec := range recs {
+		idx.byChrom[rec.Chrom] = append(idx.byChrom[rec.Chrom], rec)
+		idx.maxLen[rec.Chrom] = max(idx.maxLen[rec.Chrom], rec.End-rec.Start)
+	}
+	for _, list := range idx.byChrom {
+		sort.Slice(list, func(i, j int) bool { return list[i].Start < list[j].Start })
+	}
+	return idx
+}
+
+// Overlapping returns the intervals on chrom that overlap [start, end),
+// 0-based and half-open like BED itself.
+func (idx *IntervalIndex) Overlapping(chrom string, start, end int) []BEDRecord {
+	list := idx.byChrom[chrom]
+	from := sort.Search(len(list), func(i int) bool {
+		return list[i].Start > start-idx.maxLen[chrom]-1
+	})
+	var out []BEDRecord
+	for _, rec := range list[from:] {
+		if rec.Start >= end {
+			break
+		}
+		// zero-length intervals (insertions) overlap when they sit inside
+		if rec.End > start || (rec.Start == rec.End && rec.Start >= start) {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// Query is Overlapping for a 1-based, inclusive region.
+func (idx *IntervalIndex) Query(r Region) []BEDRecord {
+	end := r.End
+	if end == 0 {
+		end = int(^uint(0) >> 1)
+	}
+	return idx.Overlapping(r.Chrom, max(r.Start-1, 0), end)
+}
+
+// ReadBEDFile reads a plain or gzipped BED or bedGraph file.
+func ReadBEDFile(path string) (*BEDFile, error) {
+	f, err := OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	b, err := ParseBED(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var selectedRowStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("211"))
+
+type cartPage struct {
+	Title  string
+	Cursor int
+	Status string
+}
+
+func NewCartPage() *cartPage {
+	return &cartPage{
+		Title: "Cart",
+	}
+}
+
+// UpdatePage implements page.
+func (page *cartPage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
+	cart := m.Cart
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.Keys.Up):
+			page.Cursor = max(page.Cursor-1, 0)
+		case key.Matches(msg, m.Keys.Down):
+			page.Cursor = min(page.Cursor+1, max(len(cart.Items)-1, 0))
+		case key.Matches(msg, m.Keys.Mark), msg.String() == "x":
+			cart.Remove(page.Cursor)
+			page.Cursor = min(page.Cursor, max(len(cart.Items)-1, 0))
+		case msg.String() == "c":
+			cart.Clear()
+			page.Cursor = 0
+		case msg.String() == "f":
+			m.Downloads.Format = (m.Downloads.Format + 1) % len(DownloadFormats)
+		case key.Matches(msg, m.Keys.Dl):
+			if len(cart.Items) == 0 {
+				page.Status = "the cart is empty"
+				break
+			}
+			var cmds []tea.Cmd
+			for _, job := range cart.Jobs() {
+				cmds = append(cmds, m.Downloads.Enqueue(job))
+				page.Status = fmt.Sprintf("queued %d records → %s", len(job.Ids), job.Path)
+			}
+			return m, tea.Batch(cmds...)
+		}
+		m.UpdateBack(msg)
+	}
+	return m, nil
+}
+
+// Page implements page.
+func (page *cartPage) Page(m Model) string {
+	cart := m.Cart
+	p := fmt.Sprintf("%d records marked. Format: %s\n", len(cart.Items), DownloadFormats[m.Downloads.Format].Name)
+	p += faintStyle.Render("d: download all as one file • f: cycle format • x/space: remove • c: clear") + "\n\n"
+
+	if len(cart.Items) == 0 {
+		p += faintStyle.Render("mark search results with space to add them here") + "\n"
+	}
+	for idx, item := range cart.Items {
+		line := fmt.Sprintf("%-16s %-10s %s", item.Accession, item.Database, item.Definition)
+		if idx == page.Cursor {
+			line = selectedRowStyle.Render("> " + line)
+		} else {
+			line = "  " + line
+		}
+		p += line + "\n"
+	}
+
+	if page.Status != "" {
+		p += "\n" + faintStyle.Render(page.Status) + "\n"
+	}
+	return p + "\n"
+}
+
+func (page *cartPage) GetTitle() string {
+	return page.Title
+}
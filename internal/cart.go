@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"time"
+)
+
+// CartItem is a record marked for a later batch download.
+type CartItem struct {
+	Database   string
+	Accession  string // accession.version
+	Definition string
+	Length     int
+	UpdateDate string
+}
+
+// Cart collects records across searches so they can be fetched in one go.
+type Cart struct {
+	Items []CartItem
+}
+
+func NewCart() *Cart {
+	return &Cart{}
+}
+
+func CartItemFromSeq(database string, seq GBSeq) CartItem {
+	acc := seq.AccessionVersion
+	if acc == "" {
+		acc = seq.PrimaryAccession
+	}
+	return CartItem{
+		Database:   database,
+		Accession:  acc,
+		Definition: seq.Definition,
+		Length:     seq.Length,
+		UpdateDate: seq.UpdateDate,
+	}
+}
+
+func (c *Cart) index(item CartItem) int {
+	for idx, it := range c.Items {
+		if it.Database == item.Database && it.Accession == item.Accession {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (c *Cart) Contains(item CartItem) bool {
+	return c.index(item) >= 0
+}
+
+// Toggle adds the item if it isn't in the cart and removes it otherwise. It
+// reports whether the item is in the cart afterwards.
+func (c *Cart) Toggle(item CartItem) bool {
+	if idx := c.index(item); idx >= 0 {
+		c.Remove(idx)
+		return false
+	}
+	c.Items = append(c.Items, item)
+	return true
+}
+
+func (c *Cart) Remove(idx int) {
+	if idx < 0 || idx >= len(c.Items) {
+		return
+	}
+	c.Items = append(c.Items[:idx], c.Items[idx+1:]...)
+}
+
+func (c *Cart) Clear() {
+	c.Items = nil
+}
+
+// Jobs builds one batch download per database, each fetching every record
+// with a single EFetch request.
+func (c *Cart) Jobs() []*DownloadJob {
+	var jobs []*DownloadJob
+	byDB := map[string]*DownloadJob{}
+	unknown := map[string]bool{}
+	stamp := time.Now().Format("20060102-150405")
+
+	for _, item := range c.Items {
+		job, ok := byDB[item.Database]
+		if !ok {
+			job = &DownloadJob{
+				Database:  item.Database,
+				Accession: "batch-" + stamp,
+			}
+			byDB[item.Database] = job
+			jobs = append(jobs, job)
+		}
+		job.Ids = append(job.Ids, item.Accession)
+		job.UpdateDates = append(job.UpdateDates, item.UpdateDate)
+		job.Estimate += estimateSize(item.Length)
+		if item.Length <= 0 {
+			unknown[item.Database] = true
+		}
+	}
+	// one record of unknown size makes the whole estimate a guess
+	for _, job := range jobs {
+		if unknown[job.Database] {
+			job.Estimate = 0
+		}
+	}
+	return jobs
+}
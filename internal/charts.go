@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// terminal charts built from block characters
+
+var blocks = []rune(" ▁▂▃▄▅▆▇█")
+
+// Sparkline draws one character per value, scaled between lo and hi.
+func Sparkline(values []float64, lo, hi float64) string {
+	var sb strings.Builder
+	for _, v := range values {
+		sb.WriteRune(blocks[blockLevel(v, lo, hi, len(blocks)-1)])
+	}
+	return sb.String()
+}
+
+func blockLevel(v, lo, hi float64, levels int) int {
+	if hi <= lo || math.IsNaN(v) {
+		return 0
+	}
+	level := int(math.Round((v - lo) / (hi - lo) * float64(levels)))
+	return max(0, min(level, levels))
+}
+
+// Downsample averages values into at most width buckets so a long series
+// fits on screen.
+func Downsample(values []float64, width int) []float64 {
+	if len(values) <= width || width <= 0 {
+		return values
+	}
+	out := make([]float64, width)
+	for idx := range out {
+		from := idx * len(values) / width
+		to := max((idx+1)*len(values)/width, from+1)
+		sum := 0.0
+		for _, v := range values[from:to] {
+			sum += v
+		}
+		out[idx] = sum / float64(to-from)
+	}
+	return out
+}
+
+// ColumnChart draws values as vertical bars, height rows tall, with the axis
+// labels lo and hi on the left.
+func ColumnChart(values []float64, lo, hi float64, height int) string {
+	levels := height * 8
+	var sb strings.Builder
+	for row := height - 1; row >= 0; row-- {
+		switch row {
+		case height - 1:
+			sb.WriteString(fmt.Sprintf("%6.1f ┤", hi))
+		case 0:
+			sb.WriteString(fmt.Sprintf("%6.1f ┤", lo))
+		default:
+			sb.WriteString("       │")
+		}
+		for _, v := range values {
+			fill := blockLevel(v, lo, hi, levels) - row*8
+			sb.WriteRune(blocks[max(0, min(fill, 8))])
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// BarChart draws one labelled horizontal bar per value, scaled to width.
+func BarChart(labels []string, values []float64, width int) string {
+	hi, labelWidth := 0.0, 0
+	for idx, v := range values {
+		hi = max(hi, v)
+		labelWidth = max(labelWidth, len(labels[idx]))
+	}
+	var sb strings.Builder
+	for idx, v := range values {
+		n := 0
+		if hi > 0 {
+			n = int(math.Round(v / hi * float64(width)))
+		}
+		sb.WriteString(fmt.Sprintf("%*s │%s %g\n", labelWidth, labels[idx], strings.Repeat("█", n), v))
+	}
+	return sb.String()
+}
+
+// Raster is a grid of dots, drawn two dots to a character across and four
+// (braille) or two (quadrant blocks) down.
+type Raster struct {
+	Width, Height int
+	dots          []bool
+}
+
+func NewRaster(width, height int) *Raster {
+	return &Raster{Width: width, Height: height, dots: make([]bool, width*height)}
+}
+
+// Set turns on the dot at x, y, ignoring dots off the grid.
+func (r *Raster) Set(x, y int) {
+	if x >= 0 && y >= 0 && x < r.Width && y < r.Height {
+		r.dots[y*r.Width+x] = true
+	}
+}
+
+func (r *Raster) get(x, y int) bool {
+	return x < r.Width && y < r.Height && r.dots[y*r.Width+x]
+}
+
+// braille dot bits, by column and row within a character
+var brailleBits = [2][4]int{{0x01, 0x02, 0x04, 0x40}, {0x08, 0x10, 0x20, 0x80}}
+
+// quadrant blocks indexed by top left 1, top right 2, bottom left 4, bottom
+// right 8
+var quadrants = []rune(" ▘▝▀▖▌▞▛▗▚▐▜▄▙▟█")
+
+// CellRows is how many dots down one character covers.
+func CellRows(braille bool) int {
+	if braille {
+		return 4
+	}
+	return 2
+}
+
+// Cell gives the dots of one character as bits, for OR-ing rasters together
+// before drawing them with DotRune.
+func (r *Raster) Cell(col, row int, braille bool) int {
+	bits := 0
+	rows := CellRows(braille)
+	for dx := 0; dx < 2; dx++ {
+		for dy := 0; dy < rows; dy++ {
+			if !r.get(col*2+dx, row*rows+dy) {
+				continue
+			}
+			if braille {
+				bits |= brailleBits[dx][dy]
+			} else {
+				bits |= 1 << (dy*2 + dx)
+			}
+		}
+	}
+	return bits
+}
+
+// DotRune draws a character's dots.
+func DotRune(bits int, braille bool) rune {
+	if braille {
+		if bits == 0 {
+			return ' '
+		}
+		return rune(0x2800 + bits)
+	}
+	return quadrants[bits]
+}
@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type codonUsageMsg struct {
+	name  string // what was counted, for the title and file name
+	usage CodonUsage
+	err   error
+}
+
+// countCodons counts codon usage across records, fetching them whole first
+// unless they're complete already.
+func countCodons(database, name string, seqs []GBSeq, complete bool) tea.Cmd {
+	return func() tea.Msg {
+		if !complete {
+			ids := make([]string, len(seqs))
+			for idx, seq := range seqs {
+				ids[idx] = CartItemFromSeq(database, seq).Accession
+			}
+			recs, err := EFetch(database, ids, true)
+			if err != nil {
+				return codonUsageMsg{name: name, err: err}
+			}
+			seqs = recs
+		}
+		cu := CountCodons(seqs)
+		if cu.CDSs == 0 {
+			return codonUsageMsg{name: name, err: fmt.Errorf("no CDS features to count")}
+		}
+		return codonUsageMsg{name: name, usage: cu}
+	}
+}
+
+// openCodonPage shows counted codon usage, or returns the error to report.
+func openCodonPage(m *Model, msg codonUsageMsg, from string) error {
+	if msg.err != nil {
+		return msg.err
+	}
+	m.Pages[CodonPage] = NewCodonPage(msg.name, msg.usage, m.Width-20, m.Height-12)
+	m.UpdateHistory(m.Page, from)
+	m.Page = CodonPage
+	return nil
+}
+
+type codonPage struct {
+	Title    string
+	Name     string
+	Usage    CodonUsage
+	Viewport viewport.Model
+	Status   string
+}
+
+func NewCodonPage(name string, cu CodonUsage, width, height int) *codonPage {
+	v := viewport.New(width, height)
+	v.SetContent(CodonUsageView(cu))
+	return &codonPage{
+		Title:    "Codon usage of " + name,
+		Name:     name,
+		Usage:    cu,
+		Viewport: v,
+	}
+}
+
+// export writes the table in Kazusa format to the download directory.
+func (page *codonPage) export(dir string, overwrite bool) (string, error) {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(` /\:"'*?`, r) {
+			return '_'
+		}
+		return r
+	}, page.Name)
+	path := filepath.Join(dir, name+"_codon_usage.txt")
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return "", fmt.Errorf("%s already exists", path)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	err = WriteKazusa(f, page.Usage)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return path, err
+}
+
+// UpdatePage implements page.
+func (page *codonPage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.Keys.Back):
+			m.UpdateBack(msg)
+			return m, nil
+		case msg.String() == "x":
+			if path, err := page.export(m.Downloads.Dir, m.Downloads.Overwrite); err != nil {
+				page.Status = "export failed: " + err.Error()
+			} else {
+				page.Status = "exported to " + path
+			}
+			return m, nil
+		}
+
+	case tea.WindowSizeMsg:
+		page.Viewport.Width, page.Viewport.Height = msg.Width-20, msg.Height-12
+	}
+
+	var cmd tea.Cmd
+	page.Viewport, cmd = page.Viewport.Update(msg)
+	return m, cmd
+}
+
+// Page implements page.
+func (page *codonPage) Page(m Model) string {
+	p := faintStyle.Render("x: export in Kazusa/CUTG format • ↑/↓: scroll") + "\n\n"
+	p += page.Viewport.View()
+	if page.Status != "" {
+		p += "\n" + faintStyle.Render(page.Status)
+	}
+	return p + "\n\n"
+}
+
+func (page *codonPage) GetTitle() string {
+	return page.Title
+}
@@ -0,0 +1,210 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CodonUsage counts codons over the CDS features of one or more records.
+// Counts are in the genetic code tables' TCAG order.
+type CodonUsage struct {
+	Name    string // the organism, when all the records share one
+	Code    GeneticCode
+	Counts  [64]int
+	CDSs    int
+	Skipped int // pseudogenes, remote locations and the like
+}
+
+// codonName spells out the codon at a table index.
+func codonName(i int) string {
+	return string([]byte{"TCAG"[i/16], "TCAG"[i/4%4], "TCAG"[i%4]})
+}
+
+// CountCodons tallies the codons of CDS features across records. The
+// genetic code, which only matters for grouping synonymous codons, is the
+// first record's.
+func CountCodons(seqs []GBSeq) CodonUsage {
+	var cu CodonUsage
+	for idx, seq := range seqs {
+		if idx == 0 {
+			cu.Name, cu.Code = seq.Organism, RecordGeneticCode(seq)
+		} else if seq.Organism != cu.Name {
+			cu.Name = "mixed organisms"
+		}
+		cu.addRecord(seq)
+	}
+	return cu
+}
+
+func (cu *CodonUsage) addRecord(seq GBSeq) {
+	for _, f := range seq.Features {
+		if f.Key != "CDS" {
+			continue
+		}
+		if _, pseudo := f.Qualifier("pseudo"); pseudo {
+			cu.Skipped++
+			continue
+		}
+		ivs := f.Intervals
+		if len(ivs) == 0 {
+			ivs, _ = ParseLocation(f.Location)
+		}
+		cds, err := ExtractIntervals(seq.Sequence, ivs)
+		if err != nil || cds == "" {
+			cu.Skipped++
+			continue
+		}
+		// a CDS partial at its 5' end may start mid-codon
+		if v, ok := f.Qualifier("codon_start"); ok {
+			var start int
+			fmt.Sscan(v, &start)
+			cds = cds[min(len(cds), max(0, start-1)):]
+		}
+		cu.add(cds)
+		cu.CDSs++
+	}
+}
+
+// add counts the codons of one coding sequence, in frame from its first
+// base. Codons with ambiguity codes aren't counted.
+func (cu *CodonUsage) add(cds string) {
+	for p := 0; p+3 <= len(cds); p += 3 {
+		if idx := codonIndexes(cds[p : p+3]); len(idx) == 1 {
+			cu.Counts[idx[0]]++
+		}
+	}
+}
+
+// Total is the number of codons counted.
+func (cu CodonUsage) Total() int {
+	var n int
+	for _, c := range cu.Counts {
+		n += c
+	}
+	return n
+}
+
+// PerThousand is a codon's frequency per thousand codons.
+func (cu CodonUsage) PerThousand(i int) float64 {
+	total := cu.Total()
+	if total == 0 {
+		return 0
+	}
+	return 1000 * float64(cu.Counts[i]) / float64(total)
+}
+
+// RSCU is the relative synonymous codon usage: the codon's count over the
+// mean count of the codons for its amino acid, so 1 means no bias. It's 0
+// when the amino acid doesn't occur.
+func (cu CodonUsage) RSCU(i int) float64 {
+	var sum, n int
+	for j := 0; j < 64; j++ {
+		if cu.Code.AAs[j] == cu.Code.AAs[i] {
+			sum += cu.Counts[j]
+			n++
+		}
+	}
+	if sum == 0 {
+		return 0
+	}
+	return float64(cu.Counts[i]) * float64(n) / float64(sum)
+}
+
+// GC gives the GC content of the counted codons overall and at each
+// position, in percent.
+func (cu CodonUsage) GC() (all float64, byPosition [3]float64) {
+	total := cu.Total()
+	if total == 0 {
+		return 0, byPosition
+	}
+	var counts [3]int
+	for i, c := range cu.Counts {
+		codon := codonName(i)
+		for pos := 0; pos < 3; pos++ {
+			if codon[pos] == 'G' || codon[pos] == 'C' {
+				counts[pos] += c
+			}
+		}
+	}
+	for pos := range counts {
+		byPosition[pos] = 100 * float64(counts[pos]) / float64(total)
+	}
+	all = (byPosition[0] + byPosition[1] + byPosition[2]) / 3
+	return all, byPosition
+}
+
+// rare and preferred codons are colored in the table
+const (
+	rareRSCU      = 0.5
+	preferredRSCU = 1.5
+)
+
+// CodonUsageView lays the usage out like the genetic code table: each codon
+// with its amino acid, frequency per thousand, RSCU and count. Rare codons
+// are red and preferred ones green.
+func CodonUsageView(cu CodonUsage) string {
+	var sb strings.Builder
+	padding := fmt.Sprintf("%%-%ds", LabelPadding)
+	all, pos := cu.GC()
+
+	sb.WriteString("=== CODON USAGE ===\n")
+	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Organism:", cu.Name))
+	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Genetic code:", cu.Code))
+	cds := fmt.Sprint(cu.CDSs)
+	if cu.Skipped > 0 {
+		cds += fmt.Sprintf(" (%d skipped)", cu.Skipped)
+	}
+	sb.WriteString(fmt.Sprintf(padding+" %s\n", "CDS features:", cds))
+	sb.WriteString(fmt.Sprintf(padding+" %d\n", "Codons:", cu.Total()))
+	sb.WriteString(fmt.Sprintf(padding+" %.2f%%\n", "Coding GC:", all))
+	sb.WriteString(fmt.Sprintf(padding+" %.2f%% / %.2f%% / %.2f%%\n", "GC1 / GC2 / GC3:", pos[0], pos[1], pos[2]))
+
+	sb.WriteString("\n--- CODONS ---\n")
+	sb.WriteString(faintStyle.Render("codon, amino acid, per thousand, RSCU, count") + "\n\n")
+	for first := 0; first < 4; first++ {
+		for third := 0; third < 4; third++ {
+			cells := make([]string, 4)
+			for second := 0; second < 4; second++ {
+				i := first*16 + second*4 + third
+				rscu := cu.RSCU(i)
+				cell := fmt.Sprintf("%s %c %5.1f %4.2f %6d", codonName(i), cu.Code.AAs[i], cu.PerThousand(i), rscu, cu.Counts[i])
+				switch {
+				case cu.Counts[i] > 0 && rscu < rareRSCU:
+					cell = errorStyle.Render(cell)
+				case rscu > preferredRSCU:
+					cell = doneStyle.Render(cell)
+				}
+				cells[second] = cell
+			}
+			sb.WriteString(strings.Join(cells, "   ") + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// WriteKazusa writes the usage in the format of the Kazusa codon usage
+// database (and CUTG), which codon optimization tools read: RNA codons with
+// their frequency per thousand and count, four to a line.
+func WriteKazusa(w io.Writer, cu CodonUsage) error {
+	all, pos := cu.GC()
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s: %d CDS's (%d codons)\n", cu.Name, cu.CDSs, cu.Total()))
+	sb.WriteString("fields: [triplet] [frequency: per thousand] ([number])\n\n")
+	for first := 0; first < 4; first++ {
+		for third := 0; third < 4; third++ {
+			cells := make([]string, 4)
+			for second := 0; second < 4; second++ {
+				i := first*16 + second*4 + third
+				codon := strings.ReplaceAll(codonName(i), "T", "U")
+				cells[second] = fmt.Sprintf("%s %4.1f(%6d)", codon, cu.PerThousand(i), cu.Counts[i])
+			}
+			sb.WriteString(strings.Join(cells, "  ") + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(fmt.Sprintf("Coding GC %.2f%% 1st letter GC %.2f%% 2nd letter GC %.2f%% 3rd letter GC %.2f%%\n", all, pos[0], pos[1], pos[2]))
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
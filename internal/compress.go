@@ -0,0 +1,200 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+type Compression int
+
+const (
+	CompressNone Compression = iota
+	CompressGzip
+	CompressBGZF
+)
+
+var Compressions = []Compression{CompressNone, CompressGzip, CompressBGZF}
+
+func (c Compression) String() string {
+	switch c {
+	case CompressGzip:
+		return "gzip"
+	case CompressBGZF:
+		return "bgzf"
+	}
+	return "none"
+}
+
+// Suffix is appended to the file extension of compressed downloads. BGZF
+// files are valid gzip, and samtools and tabix expect them to end in .gz.
+func (c Compression) Suffix() string {
+	if c == CompressNone {
+		return ""
+	}
+	return ".gz"
+}
+
+// NewWriter wraps w so everything written is compressed on the fly. Closing
+// the returned writer flushes it but leaves w open.
+func (c Compression) NewWriter(w io.Writer, level int) (io.WriteCloser, error) {
+	switch c {
+	case CompressGzip:
+		return gzip.NewWriterLevel(w, level)
+	case CompressBGZF:
+		return NewBGZFWriter(w, level)
+	}
+	return nopWriteCloser{w}, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// BGZF is gzip made of independent members of at most 64 KiB each, with the
+// compressed size of every member stored in a "BC" extra field. That is what
+// lets samtools faidx and tabix seek into a compressed file.
+const (
+	bgzfBlockSize  = 0xff00 // uncompressed bytes per block, same as htslib
+	bgzfMaxBlock   = 0x10000
+	bgzfHeaderSize = 18
+	bgzfFooterSize = 8
+)
+
+// bgzfEOF is the empty block htslib appends to mark a complete file.
+var bgzfEOF = []byte{
+	0x1f, 0x8b, 0x08, 0x04, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0xff, 0x06, 0x00, 0x42, 0x43, 0x02, 0x00,
+	0x1b, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00,
+}
+
+type BGZFWriter struct {
+	w      io.Writer
+	buf    []byte
+	block  bytes.Buffer
+	fw     *flate.Writer
+	stored *flate.Writer
+	err    error
+}
+
+func NewBGZFWriter(w io.Writer, level int) (*BGZFWriter, error) {
+	fw, err := flate.NewWriter(nil, level)
+	if err != nil {
+		return nil, err
+	}
+	return &BGZFWriter{
+		w:   w,
+		buf: make([]byte, 0, bgzfBlockSize),
+		fw:  fw,
+	}, nil
+}
+
+func (bw *BGZFWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 && bw.err == nil {
+		c := copy(bw.buf[len(bw.buf):cap(bw.buf)], p)
+		bw.buf = bw.buf[:len(bw.buf)+c]
+		p = p[c:]
+		n += c
+		if len(bw.buf) == cap(bw.buf) {
+			bw.err = bw.flush()
+		}
+	}
+	return n, bw.err
+}
+
+func (bw *BGZFWriter) deflate(fw *flate.Writer) error {
+	bw.block.Reset()
+	fw.Reset(&bw.block)
+	if _, err := fw.Write(bw.buf); err != nil {
+		return err
+	}
+	return fw.Close()
+}
+
+// flush writes the buffered data as one complete gzip member.
+func (bw *BGZFWriter) flush() error {
+	if len(bw.buf) == 0 {
+		return nil
+	}
+	if err := bw.deflate(bw.fw); err != nil {
+		return err
+	}
+	// incompressible input can grow past the block limit, store it instead
+	if bw.block.Len()+bgzfHeaderSize+bgzfFooterSize > bgzfMaxBlock {
+		if bw.stored == nil {
+			bw.stored, _ = flate.NewWriter(nil, flate.NoCompression)
+		}
+		if err := bw.deflate(bw.stored); err != nil {
+			return err
+		}
+	}
+
+	size := bgzfHeaderSize + bw.block.Len() + bgzfFooterSize
+	header := []byte{
+		0x1f, 0x8b, 0x08, 0x04, // magic, deflate, FEXTRA
+		0x00, 0x00, 0x00, 0x00, // mtime
+		0x00, 0xff, // xfl, unknown OS
+		0x06, 0x00, // xlen
+		0x42, 0x43, 0x02, 0x00, // "BC", slen
+		0x00, 0x00, // bsize, filled below
+	}
+	binary.LittleEndian.PutUint16(header[16:], uint16(size-1))
+
+	footer := make([]byte, bgzfFooterSize)
+	binary.LittleEndian.PutUint32(footer[0:], crc32.ChecksumIEEE(bw.buf))
+	binary.LittleEndian.PutUint32(footer[4:], uint32(len(bw.buf)))
+
+	for _, b := range [][]byte{header, bw.block.Bytes(), footer} {
+		if _, err := bw.w.Write(b); err != nil {
+			return err
+		}
+	}
+	bw.buf = bw.buf[:0]
+	return nil
+}
+
+// Close flushes the last block and writes the EOF marker.
+func (bw *BGZFWriter) Close() error {
+	if bw.err != nil {
+		return bw.err
+	}
+	if bw.err = bw.flush(); bw.err != nil {
+		return bw.err
+	}
+	_, bw.err = bw.w.Write(bgzfEOF)
+	return bw.err
+}
+
+// OpenFile opens a file for reading, decompressing it on the fly if it is
+// gzip or BGZF.
+func OpenFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(f)
+	magic, _ := br.Peek(2)
+	if len(magic) < 2 || magic[0] != 0x1f || magic[1] != 0x8b {
+		return readCloser{br, f}, nil
+	}
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return readCloser{zr, f}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
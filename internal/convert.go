@@ -0,0 +1,477 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ConvertFormat is a format the converter reads and writes.
+type ConvertFormat struct {
+	Name string
+	Ext  string
+}
+
+var ConvertFormats = []ConvertFormat{
+	{Name: "genbank", Ext: "gb"},
+	{Name: "embl", Ext: "embl"},
+	{Name: "fasta", Ext: "fasta"},
+	{Name: "gff3", Ext: "gff3"},
+	{Name: "json", Ext: "json"},
+	{Name: "xml", Ext: "xml"},
+}
+
+// FindConvertFormat looks a format up by name or file extension.
+func FindConvertFormat(name string) (ConvertFormat, error) {
+	name = strings.ToLower(strings.TrimPrefix(name, "."))
+	aliases := map[string]string{"gb": "genbank", "gbk": "genbank", "fa": "fasta", "fna": "fasta", "faa": "fasta", "gff": "gff3"}
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+	for _, f := range ConvertFormats {
+		if f.Name == name || f.Ext == name {
+			return f, nil
+		}
+	}
+	names := make([]string, len(ConvertFormats))
+	for idx, f := range ConvertFormats {
+		names[idx] = f.Name
+	}
+	return ConvertFormat{}, fmt.Errorf("unknown format %q, expected one of %s", name, strings.Join(names, ", "))
+}
+
+// SniffFormat guesses a format from the start of the content, without
+// consuming it.
+func SniffFormat(br *bufio.Reader) (string, error) {
+	head, err := br.Peek(4096)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return "", err
+	}
+	var line string
+	for _, l := range strings.Split(string(head), "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			line = l
+			break
+		}
+	}
+	switch {
+	case line == "":
+		return "", fmt.Errorf("input is empty")
+	case strings.HasPrefix(line, "LOCUS"):
+		return "genbank", nil
+	case strings.HasPrefix(line, "ID   "):
+		return "embl", nil
+	case strings.HasPrefix(line, ">"):
+		return "fasta", nil
+	case strings.HasPrefix(line, "##gff-version") || strings.Count(line, "\t") == 8:
+		return "gff3", nil
+	case strings.HasPrefix(line, "{") || strings.HasPrefix(line, "["):
+		return "json", nil
+	case strings.HasPrefix(line, "<"):
+		return "xml", nil
+	}
+	return "", fmt.Errorf("can't tell the format from %q", line)
+}
+
+// ReadRecords parses records of the given format, or of the sniffed format
+// if it's empty, into GBSeq.
+func ReadRecords(r io.Reader, format string) ([]GBSeq, string, error) {
+	br := bufio.NewReaderSize(r, 1<<16)
+	if format == "" {
+		var err error
+		if format, err = SniffFormat(br); err != nil {
+			return nil, "", err
+		}
+	}
+
+	var recs []GBSeq
+	var err error
+	switch format {
+	case "genbank":
+		recs, err = readAll(NewGenBankReader(br).Read)
+	case "embl":
+		recs, err = readAll(NewEMBLReader(br).Read)
+	case "fasta":
+		fr := NewFastaReader(br)
+		recs, err = readAll(func() (GBSeq, error) {
+			rec, err := fr.Read()
+			return rec.GBSeq(), err
+		})
+	case "gff3":
+		var g *GFFFile
+		if g, err = ParseGFF(br); err == nil {
+			recs = GFFRecords(g)
+		}
+	case "json":
+		recs, err = readJSONRecords(br)
+	case "xml":
+		var set GBSet
+		err = xml.NewDecoder(br).Decode(&set)
+		recs = set.Sequences
+	default:
+		_, err = FindConvertFormat(format)
+	}
+	return recs, format, err
+}
+
+func readAll(read func() (GBSeq, error)) ([]GBSeq, error) {
+	var recs []GBSeq
+	for {
+		seq, err := read()
+		if err == io.EOF {
+			return recs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, seq)
+	}
+}
+
+// readJSONRecords accepts what WriteRecords writes (an array) as well as a
+// single record.
+func readJSONRecords(br *bufio.Reader) ([]GBSeq, error) {
+	var recs []GBSeq
+	first, err := br.Peek(1)
+	for err == nil && (first[0] == ' ' || first[0] == '\n' || first[0] == '\r' || first[0] == '\t') {
+		br.ReadByte()
+		first, err = br.Peek(1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if first[0] == '{' {
+		var seq GBSeq
+		err = json.NewDecoder(br).Decode(&seq)
+		return []GBSeq{seq}, err
+	}
+	err = json.NewDecoder(br).Decode(&recs)
+	return recs, err
+}
+
+// WriteRecords writes records in any of ConvertFormats.
+func WriteRecords(w io.Writer, format string, recs []GBSeq) error {
+	switch format {
+	case "genbank", "embl":
+		write := WriteGenBank
+		if format == "embl" {
+			write = WriteEMBL
+		}
+		for _, seq := range recs {
+			if err := write(w, seq); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "fasta":
+		out := make([]FastaRecord, len(recs))
+		for idx, seq := range recs {
+			out[idx] = FastaRecord{ID: recordName(seq), Description: seq.Definition, Sequence: seq.Sequence}
+		}
+		return WriteFasta(w, out)
+	case "gff3":
+		return WriteGFF3(w, recs)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(recs)
+	case "xml":
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "  ")
+		if err := enc.Encode(GBSet{Sequences: recs}); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	}
+	_, err := FindConvertFormat(format)
+	return err
+}
+
+// recordName is the best identifier a record has.
+func recordName(seq GBSeq) string {
+	for _, name := range []string{seq.AccessionVersion, seq.PrimaryAccession, seq.Locus} {
+		if name != "" {
+			return name
+		}
+	}
+	return "unnamed"
+}
+
+// GFF3 attributes with an INSDC qualifier of the same meaning
+var gffQualifiers = map[string]string{"Note": "note", "Dbxref": "db_xref"}
+
+// GFFRecords turns an annotation file into one record per sequence, with a
+// feature per ID (split CDS lines are joined back up) or per line.
+func GFFRecords(g *GFFFile) []GBSeq {
+	seqs := map[string]string{}
+	for _, rec := range g.Sequences {
+		seqs[rec.ID] = rec.Sequence
+	}
+	lengths := map[string]int{}
+	for _, d := range g.Directives {
+		// ##sequence-region seqid start end
+		if f := strings.Fields(d); len(f) == 4 && f[0] == "##sequence-region" {
+			lengths[f[1]], _ = strconv.Atoi(f[3])
+		}
+	}
+
+	var order []string
+	byID := map[string]*GBSeq{}
+	record := func(id string) *GBSeq {
+		if seq, ok := byID[id]; ok {
+			return seq
+		}
+		seq := &GBSeq{Locus: id, PrimaryAccession: id, Sequence: seqs[id], Length: lengths[id]}
+		if seq.Sequence != "" {
+			seq.Length = len(seq.Sequence)
+			seq.MolType = GuessMolType(seq.Sequence)
+		}
+		byID[id] = seq
+		order = append(order, id)
+		return seq
+	}
+
+	type key struct{ seqid, typ, id string }
+	joined := map[key]int{}
+	for _, f := range g.Features {
+		seq := record(f.SeqID)
+		seq.Length = max(seq.Length, f.End)
+
+		iv := GBInterval{From: f.Start, To: f.End}
+		if f.Strand == "-" {
+			iv.From, iv.To = iv.To, iv.From
+		}
+		k := key{f.SeqID, f.Type, f.Attr("ID")}
+		if idx, ok := joined[k]; ok && k.id != "" {
+			feat := &seq.Features[idx]
+			feat.Intervals = append(feat.Intervals, iv)
+			continue
+		}
+
+		feat := GBFeature{Key: f.Type, Intervals: []GBInterval{iv}}
+		if f.Type == "region" && len(seq.Features) == 0 {
+			feat.Key = "source"
+			seq.Organism = f.Attr("organism")
+		}
+		for _, a := range f.Attributes {
+			// the hierarchy has no INSDC equivalent
+			if a.Key == "ID" || a.Key == "Parent" {
+				continue
+			}
+			name := a.Key
+			if q, ok := gffQualifiers[name]; ok {
+				name = q
+			}
+			for _, v := range a.Values {
+				feat.Quals = append(feat.Quals, GBQualifier{Name: name, Value: v})
+			}
+		}
+		if f.Type == "CDS" && f.Phase != "" && f.Phase != "." && f.Phase != "0" {
+			n, _ := strconv.Atoi(f.Phase)
+			feat.Quals = append(feat.Quals, GBQualifier{Name: "codon_start", Value: strconv.Itoa(n + 1)})
+		}
+		joined[k] = len(seq.Features)
+		seq.Features = append(seq.Features, feat)
+	}
+	for _, rec := range g.Sequences {
+		record(rec.ID)
+	}
+
+	recs := make([]GBSeq, len(order))
+	for idx, id := range order {
+		seq := byID[id]
+		for i := range seq.Features {
+			feat := &seq.Features[i]
+			// minus strand parts are listed in file order, put them in
+			// transcript order
+			if len(feat.Intervals) > 1 && feat.Intervals[0].From > feat.Intervals[0].To {
+				slices.SortFunc(feat.Intervals, func(a, b GBInterval) int { return b.From - a.From })
+			}
+			feat.Location = FormatLocation(feat.Intervals)
+		}
+		recs[idx] = *seq
+	}
+	return recs
+}
+
+var gffEscaper = strings.NewReplacer("%", "%25", ";", "%3B", "=", "%3D", "&", "%26", ",", "%2C", "\t", "%09", "\n", "%0A")
+
+// WriteGFF3 writes every record's features as GFF3, with the sequences in a
+// ##FASTA section at the end. Features in several pieces get a line per
+// piece sharing one ID.
+func WriteGFF3(w io.Writer, recs []GBSeq) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("##gff-version 3\n")
+	for _, seq := range recs {
+		fmt.Fprintf(bw, "##sequence-region %s 1 %d\n", recordName(seq), max(seq.Length, len(seq.Sequence)))
+	}
+
+	toGFF := map[string]string{}
+	for k, v := range gffQualifiers {
+		toGFF[v] = k
+	}
+	for _, seq := range recs {
+		name := recordName(seq)
+		counts := map[string]int{}
+		for _, feat := range seq.Features {
+			ivs := feat.Intervals
+			if len(ivs) == 0 {
+				ivs, _ = ParseLocation(feat.Location)
+			}
+			typ := feat.Key
+			if typ == "source" {
+				typ = "region"
+			}
+
+			var attrs []string
+			id, hasID := feat.Qualifier("ID")
+			if !hasID && len(ivs) > 1 {
+				counts[typ]++
+				id = fmt.Sprintf("%s-%d", typ, counts[typ])
+				attrs = append(attrs, "ID="+gffEscaper.Replace(id))
+			}
+			phase0 := 0
+			for _, q := range feat.Quals {
+				if q.Name == "translation" {
+					continue
+				}
+				if q.Name == "codon_start" {
+					n, _ := strconv.Atoi(q.Value)
+					phase0 = max(n-1, 0)
+					continue
+				}
+				key := q.Name
+				if k, ok := toGFF[key]; ok {
+					key = k
+				}
+				value := q.Value
+				if value == "" {
+					value = "true"
+				}
+				attrs = append(attrs, gffEscaper.Replace(key)+"="+gffEscaper.Replace(value))
+			}
+			col9 := strings.Join(attrs, ";")
+			if col9 == "" {
+				col9 = "."
+			}
+
+			done := 0 // bases in earlier pieces, for the CDS phase
+			for _, iv := range ivs {
+				if iv.Accession != "" && iv.Accession != name {
+					continue
+				}
+				from, to, strand := iv.From, iv.To, "+"
+				if from > to {
+					from, to, strand = to, from, "-"
+				}
+				phase := "."
+				if typ == "CDS" {
+					phase = strconv.Itoa((3 - (done-phase0)%3) % 3)
+					if done == 0 {
+						phase = strconv.Itoa(phase0)
+					}
+				}
+				done += to - from + 1
+				fmt.Fprintf(bw, "%s\t.\t%s\t%d\t%d\t.\t%s\t%s\t%s\n", gffEscaper.Replace(name), typ, from, to, strand, phase, col9)
+			}
+		}
+	}
+
+	var fasta []FastaRecord
+	for _, seq := range recs {
+		if seq.Sequence != "" {
+			fasta = append(fasta, FastaRecord{ID: recordName(seq), Sequence: seq.Sequence})
+		}
+	}
+	if len(fasta) > 0 {
+		bw.WriteString("##FASTA\n")
+		if err := WriteFasta(bw, fasta); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Convert reads records from r and writes them to w in the format named to.
+// An empty from means sniff it. It returns the input format and the record
+// count.
+func Convert(r io.Reader, w io.Writer, from, to string) (string, int, error) {
+	target, err := FindConvertFormat(to)
+	if err != nil {
+		return "", 0, err
+	}
+	if from != "" {
+		f, err := FindConvertFormat(from)
+		if err != nil {
+			return "", 0, err
+		}
+		from = f.Name
+	}
+	recs, from, err := ReadRecords(r, from)
+	if err != nil {
+		return from, 0, err
+	}
+	return from, len(recs), WriteRecords(w, target.Name, recs)
+}
+
+// ConvertFile is Convert between files; in may be "-" for stdin. The output
+// is written alongside out and renamed into place, so a failed conversion
+// leaves nothing behind. An existing out is only replaced if overwrite is
+// set, and never when it's the input itself.
+func ConvertFile(in, out, from, to string, overwrite bool) (string, int, error) {
+	if info, err := os.Stat(out); err == nil {
+		if inInfo, err := os.Stat(in); err == nil && os.SameFile(info, inInfo) {
+			return "", 0, fmt.Errorf("%s is the input file", out)
+		}
+		if !overwrite {
+			return "", 0, fmt.Errorf("%s already exists", out)
+		}
+	}
+	r := io.ReadCloser(os.Stdin)
+	if in != "-" {
+		var err error
+		if r, err = OpenFile(in); err != nil {
+			return "", 0, err
+		}
+		defer r.Close()
+	}
+
+	tmp := out + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return "", 0, err
+	}
+	from, n, err := Convert(r, f, from, to)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return from, 0, fmt.Errorf("%s: %v", in, err)
+	}
+	if err := os.Rename(tmp, out); err != nil {
+		os.Remove(tmp)
+		return from, 0, err
+	}
+	return from, n, nil
+}
+
+// ConvertedName is where a converted copy of path goes in dir.
+func ConvertedName(path, dir string, to ConvertFormat) string {
+	base := filepath.Base(path)
+	base = strings.TrimSuffix(base, ".gz")
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	return filepath.Join(dir, base+"."+to.Ext)
+}
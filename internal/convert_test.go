@@ -0,0 +1,185 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testRecord() GBSeq {
+	return GBSeq{
+		Locus:            "AB000001",
+		Length:           30,
+		MolType:          "DNA",
+		Topology:         "linear",
+		Division:         "PLN",
+		Definition:       "Arabidopsis thaliana abc gene for a test protein, complete cds",
+		PrimaryAccession: "AB000001",
+		AccessionVersion: "AB000001.1",
+		Organism:         "Arabidopsis thaliana",
+		Taxonomy:         "Eukaryota; Viridiplantae",
+		Keywords:         []string{"test"},
+		Sequence:         "atggcgtaaccgattacgatcgatcgatgc",
+		Features: []GBFeature{
+			{Key: "source", Location: "1..30", Quals: []GBQualifier{
+				{Name: "organism", Value: "Arabidopsis thaliana"},
+				{Name: "mol_type", Value: "genomic DNA"},
+			}},
+			{Key: "gene", Location: "1..20", Quals: []GBQualifier{{Name: "gene", Value: "abc"}}},
+			{Key: "CDS", Location: "join(2..6,10..20)", Quals: []GBQualifier{
+				{Name: "gene", Value: "abc"},
+				{Name: "codon_start", Value: "2"},
+				{Name: "note", Value: "a note; with, punctuation"},
+			}},
+			{Key: "misc_feature", Location: "complement(join(21..24,26..30))", Quals: []GBQualifier{
+				{Name: "note", Value: "minus strand"},
+			}},
+		},
+	}
+}
+
+func TestSniffFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"genbank", "LOCUS       AB000001  30 bp    DNA\n", "genbank", false},
+		{"embl after blank lines", "\n\nID   AB000001; SV 1; linear\n", "embl", false},
+		{"fasta", ">seq1 a sequence\nACGT\n", "fasta", false},
+		{"gff3 header", "##gff-version 3\nchr1\t.\tgene\t1\t10\t.\t+\t.\tID=g1\n", "gff3", false},
+		{"gff without header", "chr1\t.\tgene\t1\t10\t.\t+\t.\tID=g1\n", "gff3", false},
+		{"json array", "[\n  {\"Locus\": \"X\"}\n]\n", "json", false},
+		{"json object", "  {\"Locus\": \"X\"}\n", "json", false},
+		{"xml", "<?xml version=\"1.0\"?>\n<GBSet></GBSet>\n", "xml", false},
+		{"empty", "\n  \n", "", true},
+		{"unknown", "hello world\n", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			br := bufio.NewReader(strings.NewReader(tt.input))
+			got, err := SniffFormat(br)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SniffFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("SniffFormat() = %q, want %q", got, tt.want)
+			}
+			// sniffing mustn't eat the input
+			rest, _ := io.ReadAll(br)
+			if string(rest) != tt.input {
+				t.Errorf("SniffFormat() consumed input, %q left", rest)
+			}
+		})
+	}
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	tests := []struct {
+		format string
+		lossy  bool // GFF3 only keeps features and sequence
+	}{
+		{"genbank", false},
+		{"embl", false},
+		{"json", false},
+		{"gff3", true},
+	}
+	want := testRecord()
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteRecords(&buf, tt.format, []GBSeq{want}); err != nil {
+				t.Fatalf("WriteRecords() error = %v", err)
+			}
+			recs, from, err := ReadRecords(&buf, "")
+			if err != nil {
+				t.Fatalf("ReadRecords() error = %v", err)
+			}
+			if from != tt.format {
+				t.Errorf("sniffed %q, want %q", from, tt.format)
+			}
+			if len(recs) != 1 {
+				t.Fatalf("read %d records, want 1", len(recs))
+			}
+			got := recs[0]
+
+			if got.Sequence != want.Sequence || got.Length != want.Length {
+				t.Errorf("sequence = %q (%d), want %q (%d)", got.Sequence, got.Length, want.Sequence, want.Length)
+			}
+			if !tt.lossy {
+				if got.AccessionVersion != want.AccessionVersion || got.Definition != want.Definition ||
+					got.Organism != want.Organism || got.Taxonomy != want.Taxonomy ||
+					!reflect.DeepEqual(got.Keywords, want.Keywords) {
+					t.Errorf("header = %q %q %q %q %v, want %q %q %q %q %v",
+						got.AccessionVersion, got.Definition, got.Organism, got.Taxonomy, got.Keywords,
+						want.AccessionVersion, want.Definition, want.Organism, want.Taxonomy, want.Keywords)
+				}
+			}
+
+			if len(got.Features) != len(want.Features) {
+				t.Fatalf("read %d features, want %d", len(got.Features), len(want.Features))
+			}
+			for idx, wf := range want.Features {
+				gf := got.Features[idx]
+				if gf.Key != wf.Key || gf.Location != wf.Location {
+					t.Errorf("feature %d = %s %s, want %s %s", idx, gf.Key, gf.Location, wf.Key, wf.Location)
+				}
+				if !tt.lossy {
+					if !reflect.DeepEqual(gf.Quals, wf.Quals) {
+						t.Errorf("feature %d qualifiers = %v, want %v", idx, gf.Quals, wf.Quals)
+					}
+					continue
+				}
+				// GFF3 may reorder qualifiers, but keeps their values
+				for _, q := range wf.Quals {
+					if v, ok := gf.Qualifier(q.Name); !ok || v != q.Value {
+						t.Errorf("feature %d /%s = %q, want %q", idx, q.Name, v, q.Value)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestWriteGFF3CDSPhase(t *testing.T) {
+	tests := []struct {
+		name       string
+		location   string
+		codonStart string
+		want       []string // phase of each line, in file order
+	}{
+		{"single piece", "1..30", "", []string{"0"}},
+		{"codon_start 3", "1..30", "3", []string{"2"}},
+		{"two pieces", "join(1..4,8..15)", "", []string{"0", "2"}},
+		{"two pieces from codon_start 2", "join(2..6,10..20)", "2", []string{"1", "2"}},
+		{"three pieces", "join(3..7,20..25,40..50)", "", []string{"0", "1", "1"}},
+		{"minus strand", "complement(join(1..5,9..14))", "", []string{"0", "0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cds := GBFeature{Key: "CDS", Location: tt.location}
+			if tt.codonStart != "" {
+				cds.Quals = []GBQualifier{{Name: "codon_start", Value: tt.codonStart}}
+			}
+			seq := GBSeq{AccessionVersion: "X.1", Length: 50, Features: []GBFeature{cds}}
+
+			var buf bytes.Buffer
+			if err := WriteGFF3(&buf, []GBSeq{seq}); err != nil {
+				t.Fatalf("WriteGFF3() error = %v", err)
+			}
+			var got []string
+			for _, line := range strings.Split(buf.String(), "\n") {
+				if cols := strings.Split(line, "\t"); len(cols) == 9 && cols[2] == "CDS" {
+					got = append(got, cols[7])
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("phases = %v, want %v\n%s", got, tt.want, buf.String())
+			}
+		})
+	}
+}
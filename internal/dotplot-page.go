@@ -0,0 +1,276 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// the smallest region zooming in goes down to
+const dotPlotMinRegion = 20
+
+type dotPlotPage struct {
+	Title          string
+	NameX, NameY   string
+	X, Y           string
+	Self           bool
+	Mixed          bool // a protein against a nucleotide sequence
+	Options        DotPlotOptions
+	XRange, YRange [2]int // 0-based, end exclusive
+	Braille        bool
+	Plot           *DotPlot
+	Plotting       bool
+	run            int // counts plots started, so stale ones are dropped
+	Zoom           textinput.Model
+	Zooming        bool // typing a region
+	Width, Height  int  // characters available for the plot
+	Status         string
+	Err            error
+}
+
+// NewDotPlotPage compares x, across, with y, down; pass the same record
+// twice for a self comparison.
+func NewDotPlotPage(x, y GBSeq, width, height int) *dotPlotPage {
+	z := textinput.New()
+	z.Prompt = "Zoom to: "
+	z.Placeholder = "1000-5000, or 1000-5000 2000-6000 for x then y"
+	z.Width = 50
+
+	page := &dotPlotPage{
+		NameX:   CartItemFromSeq("nuccore", x).Accession,
+		NameY:   CartItemFromSeq("nuccore", y).Accession,
+		X:       x.Sequence,
+		Y:       y.Sequence,
+		Braille: true,
+		Zoom:    z,
+		Options: DotPlotOptions{Word: 11, Reverse: true, Protein: IsProtein(x)},
+	}
+	page.Self = page.NameX == page.NameY
+	page.Mixed = IsProtein(x) != IsProtein(y)
+	page.Title = "Dot plot of " + page.NameX + " against " + page.NameY
+	if page.Self {
+		page.Title = "Dot plot of " + page.NameX + " against itself"
+	}
+	if page.Options.Protein {
+		page.Options.Word = 3
+	}
+	page.Options.Window = page.Options.Word
+	page.resize(width, height)
+	page.XRange, page.YRange = [2]int{0, len(page.X)}, [2]int{0, len(page.Y)}
+	return page
+}
+
+// Start draws the first plot.
+func (page *dotPlotPage) Start() tea.Cmd {
+	return page.plot()
+}
+
+func (page *dotPlotPage) resize(width, height int) {
+	// leave room for the axis labels and the settings
+	page.Width, page.Height = max(10, width-14), max(5, height-10)
+}
+
+func (page *dotPlotPage) reset() tea.Cmd {
+	page.XRange, page.YRange = [2]int{0, len(page.X)}, [2]int{0, len(page.Y)}
+	return page.plot()
+}
+
+type dotPlotMsg struct {
+	page *dotPlotPage
+	run  int
+	plot *DotPlot
+	err  error
+}
+
+// plot redraws the current regions in the background. The last plot stays
+// up until the new one is ready.
+func (page *dotPlotPage) plot() tea.Cmd {
+	page.run++
+	if page.Mixed {
+		page.Plot, page.Err = nil, fmt.Errorf("can't compare a protein with a nucleotide sequence")
+		return nil
+	}
+	page.Plotting = true
+	x, y, xr, yr, opts := page.X, page.Y, page.XRange, page.YRange, page.Options
+	width, height := page.Width*2, page.Height*CellRows(page.Braille)
+	run := page.run
+	return func() tea.Msg {
+		dp, err := ComputeDotPlot(x, y, xr, yr, opts, width, height)
+		return dotPlotMsg{page: page, run: run, plot: dp, err: err}
+	}
+}
+
+// zoom scales both regions around their centers, keeping them inside the
+// sequences.
+func (page *dotPlotPage) zoom(factor float64) tea.Cmd {
+	page.XRange = zoomRange(page.XRange, factor, len(page.X))
+	page.YRange = zoomRange(page.YRange, factor, len(page.Y))
+	return page.plot()
+}
+
+func zoomRange(r [2]int, factor float64, length int) [2]int {
+	size := min(length, max(dotPlotMinRegion, int(float64(r[1]-r[0])*factor)))
+	center := (r[0] + r[1]) / 2
+	return panRange([2]int{center - size/2, center - size/2 + size}, 0, length)
+}
+
+// panRange moves a region by some bases, stopping at the ends.
+func panRange(r [2]int, by, length int) [2]int {
+	size := r[1] - r[0]
+	from := max(0, min(r[0]+by, length-size))
+	return [2]int{from, from + size}
+}
+
+// updateZoom handles typing in the region prompt.
+func (page *dotPlotPage) updateZoom(msg tea.KeyMsg) tea.Cmd {
+	if msg.String() != "enter" {
+		var cmd tea.Cmd
+		page.Zoom, cmd = page.Zoom.Update(msg)
+		return cmd
+	}
+	page.Zooming = false
+	page.Zoom.Blur()
+	fields := strings.Fields(page.Zoom.Value())
+	if len(fields) == 0 {
+		return nil
+	}
+	if len(fields) > 2 {
+		page.Status = "expected one range, or one for x and one for y"
+		return nil
+	}
+	xs, err := ParseSelection(fields[0], len(page.X))
+	ys := xs
+	if err == nil && len(fields) == 2 {
+		ys, err = ParseSelection(fields[1], len(page.Y))
+	} else if err == nil && ys.End > len(page.Y) {
+		err = fmt.Errorf("%d-%d is outside %s, give a range for y too", ys.Start, ys.End, page.NameY)
+	}
+	if err != nil {
+		page.Status = err.Error()
+		return nil
+	}
+	page.Status = ""
+	page.XRange, page.YRange = [2]int{xs.Start - 1, xs.End}, [2]int{ys.Start - 1, ys.End}
+	return page.plot()
+}
+
+// UpdatePage implements page.
+func (page *dotPlotPage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if page.Zooming {
+			return m, page.updateZoom(msg)
+		}
+		step := func(r [2]int) int { return max(1, (r[1]-r[0])/4) }
+		switch {
+		case key.Matches(msg, m.Keys.Back):
+			m.UpdateBack(msg)
+			return m, nil
+		case msg.String() == "k" || msg.String() == "K":
+			if msg.String() == "k" {
+				page.Options.Word = max(2, page.Options.Word-1)
+			} else {
+				page.Options.Word = min(32, page.Options.Word+1)
+			}
+			page.Options.Window = max(page.Options.Window, page.Options.Word)
+			return m, page.plot()
+		case msg.String() == "w":
+			page.Options.Window = max(page.Options.Word, page.Options.Window-5)
+			return m, page.plot()
+		case msg.String() == "W":
+			page.Options.Window += 5
+			return m, page.plot()
+		case msg.String() == "s" && !page.Options.Protein:
+			page.Options.Reverse = !page.Options.Reverse
+			return m, page.plot()
+		case msg.String() == "b":
+			page.Braille = !page.Braille
+			return m, page.plot()
+		case msg.String() == "+" || msg.String() == "=":
+			return m, page.zoom(0.5)
+		case msg.String() == "-":
+			return m, page.zoom(2)
+		case msg.String() == "0":
+			return m, page.reset()
+		case msg.String() == "left":
+			page.XRange = panRange(page.XRange, -step(page.XRange), len(page.X))
+			return m, page.plot()
+		case msg.String() == "right":
+			page.XRange = panRange(page.XRange, step(page.XRange), len(page.X))
+			return m, page.plot()
+		case msg.String() == "up":
+			page.YRange = panRange(page.YRange, -step(page.YRange), len(page.Y))
+			return m, page.plot()
+		case msg.String() == "down":
+			page.YRange = panRange(page.YRange, step(page.YRange), len(page.Y))
+			return m, page.plot()
+		case msg.String() == "z":
+			page.Zooming = true
+			return m, page.Zoom.Focus()
+		}
+		return m, nil
+
+	case dotPlotMsg:
+		if msg.run != page.run {
+			return m, nil
+		}
+		page.Plotting = false
+		page.Plot, page.Err = msg.plot, msg.err
+		return m, nil
+
+	case tea.WindowSizeMsg:
+		page.resize(msg.Width-20, msg.Height-8)
+		return m, page.plot()
+	}
+	return m, nil
+}
+
+// Page implements page.
+func (page *dotPlotPage) Page(m Model) string {
+	o := page.Options
+	p := fmt.Sprintf("x: %s %d-%d • y: %s %d-%d\n", page.NameX, page.XRange[0]+1, page.XRange[1], page.NameY, page.YRange[0]+1, page.YRange[1])
+	settings := fmt.Sprintf("word %d, runs of at least %d", o.Word, o.Window)
+	if !o.Protein {
+		strands := "forward strand only"
+		if o.Reverse {
+			strands = "both strands, " + inversionStyle.Render("reverse in blue")
+		}
+		settings += ", " + strands
+	}
+	if page.Plot != nil {
+		settings += fmt.Sprintf(", %.1f per dot", page.Plot.Scale)
+	}
+	p += settings + "\n"
+	help := "k/K: word size • w/W: window • b: braille/blocks • +/-: zoom • arrows: pan • z: zoom to a region • 0: reset"
+	if !o.Protein {
+		help = "s: strands • " + help
+	}
+	p += faintStyle.Render(help) + "\n\n"
+	if page.Plotting {
+		p += faintStyle.Render("plotting ...") + "\n"
+	}
+
+	switch {
+	case page.Err != nil:
+		p += errorStyle.Render(page.Err.Error()) + "\n"
+	case page.Plot != nil:
+		p += DotPlotView(page.Plot, page.Braille)
+		if page.Plot.Truncated {
+			p += errorStyle.Render(fmt.Sprintf("stopped after %d word hits, raise the word size or zoom in", DotPlotHitLimit)) + "\n"
+		}
+	}
+	if page.Zooming {
+		p += "\n" + page.Zoom.View()
+	}
+	if page.Status != "" {
+		p += "\n" + faintStyle.Render(page.Status)
+	}
+	return p + "\n"
+}
+
+func (page *dotPlotPage) GetTitle() string {
+	return page.Title
+}
@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// limits on a dot plot: the longest region compared, and how many word hits
+// are looked at before giving up on the rest
+const (
+	DotPlotMaxLength = 2_000_000
+	DotPlotHitLimit  = 20_000_000
+)
+
+// DotPlotOptions set what makes a dot.
+type DotPlotOptions struct {
+	Word    int  // length of the exact matches looked for
+	Window  int  // the shortest run of matching bases drawn, at least Word
+	Reverse bool // also match against y's reverse complement, for inversions
+	Protein bool
+}
+
+// DotPlot compares a region of x, across, with a region of y, down. Runs on
+// y's reverse strand go up and to the right.
+type DotPlot struct {
+	X, Y      [2]int  // 0-based, end exclusive
+	Scale     float64 // bases per dot, the same both ways so repeats lie at 45°
+	Forward   *Raster
+	Reverse   *Raster
+	Truncated bool // hit DotPlotHitLimit
+}
+
+// ComputeDotPlot finds the runs of matching bases between the regions and
+// draws those at least opts.Window long on a grid of at most width by
+// height dots.
+func ComputeDotPlot(x, y string, xr, yr [2]int, opts DotPlotOptions, width, height int) (*DotPlot, error) {
+	if xr[1]-xr[0] > DotPlotMaxLength || yr[1]-yr[0] > DotPlotMaxLength {
+		return nil, fmt.Errorf("regions longer than %d can't be plotted, zoom in first", DotPlotMaxLength)
+	}
+	x, y = strings.ToUpper(x), strings.ToUpper(y)
+	scale := max(1, float64(xr[1]-xr[0])/float64(width), float64(yr[1]-yr[0])/float64(height))
+	dp := &DotPlot{X: xr, Y: yr, Scale: scale}
+	w := int(float64(xr[1]-xr[0])/scale + 0.999)
+	h := int(float64(yr[1]-yr[0])/scale + 0.999)
+	dp.Forward, dp.Reverse = NewRaster(w, h), NewRaster(w, h)
+
+	// unknown residues never match
+	unknown := byte('N')
+	if opts.Protein {
+		unknown = 'X'
+	}
+	k := opts.Word
+	index := map[string][]int{}
+	for i := xr[0]; i+k <= xr[1]; i++ {
+		if word := x[i : i+k]; strings.IndexByte(word, unknown) < 0 {
+			index[word] = append(index[word], i)
+		}
+	}
+	same := func(a, b byte) bool { return a == b && a != unknown }
+	px := func(i int) int { return int(float64(i-xr[0]) / scale) }
+	py := func(j int) int { return int(float64(j-yr[0]) / scale) }
+
+	hits := 0
+	// scan compares x with other, a region of y or its reverse complement,
+	// drawing each run once from its first word
+	scan := func(other string, draw func(i, p, l int)) {
+		for p := 0; p+k <= len(other); p++ {
+			for _, i := range index[other[p:p+k]] {
+				if hits++; hits > DotPlotHitLimit {
+					dp.Truncated = true
+					return
+				}
+				if i > xr[0] && p > 0 && same(x[i-1], other[p-1]) {
+					continue
+				}
+				l := k
+				for i+l < xr[1] && p+l < len(other) && same(x[i+l], other[p+l]) {
+					l++
+				}
+				if l >= opts.Window {
+					draw(i, p, l)
+				}
+			}
+		}
+	}
+
+	scan(y[yr[0]:yr[1]], func(i, p, l int) {
+		for t := 0; t < l; t++ {
+			dp.Forward.Set(px(i+t), py(yr[0]+p+t))
+		}
+	})
+	if opts.Reverse && !opts.Protein && !dp.Truncated {
+		scan(ReverseComplement(y[yr[0]:yr[1]]), func(i, p, l int) {
+			for t := 0; t < l; t++ {
+				dp.Reverse.Set(px(i+t), py(yr[1]-1-p-t))
+			}
+		})
+	}
+	return dp, nil
+}
+
+var inversionStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+
+// DotPlotView draws the plot with the coordinates of its corners. Characters
+// with only reverse strand matches are blue.
+func DotPlotView(dp *DotPlot, braille bool) string {
+	var sb strings.Builder
+	rows := CellRows(braille)
+	cols := (dp.Forward.Width + 1) / 2
+	lines := (dp.Forward.Height + rows - 1) / rows
+	for row := 0; row < lines; row++ {
+		switch row {
+		case 0:
+			sb.WriteString(fmt.Sprintf("%10d ┤", dp.Y[0]+1))
+		case lines - 1:
+			sb.WriteString(fmt.Sprintf("%10d ┤", dp.Y[1]))
+		default:
+			sb.WriteString(strings.Repeat(" ", 11) + "│")
+		}
+		for col := 0; col < cols; col++ {
+			fwd := dp.Forward.Cell(col, row, braille)
+			rev := dp.Reverse.Cell(col, row, braille)
+			c := string(DotRune(fwd|rev, braille))
+			if fwd == 0 && rev != 0 {
+				c = inversionStyle.Render(c)
+			}
+			sb.WriteString(c)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(strings.Repeat(" ", 11) + "└" + strings.Repeat("─", cols) + "\n")
+	from, to := fmt.Sprint(dp.X[0]+1), fmt.Sprint(dp.X[1])
+	sb.WriteString(strings.Repeat(" ", 12) + from + strings.Repeat(" ", max(1, cols-len(from)-len(to))) + to + "\n")
+	return sb.String()
+}
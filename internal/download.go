@@ -0,0 +1,450 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// DownloadFormat describes how a record is requested from EFetch and what
+// extension the resulting file gets.
+type DownloadFormat struct {
+	Name    string
+	RetType string
+	RetMode string
+	Ext     string
+}
+
+var DownloadFormats = []DownloadFormat{
+	{Name: "fasta", RetType: "fasta", RetMode: "text", Ext: "fasta"},
+	{Name: "genbank", RetType: "gb", RetMode: "text", Ext: "gb"},
+	{Name: "xml", RetType: "gb", RetMode: "xml", Ext: "xml"},
+}
+
+type downloadStatus int
+
+const (
+	downloadQueued downloadStatus = iota
+	downloadActive
+	downloadDone
+	downloadFailed
+)
+
+func (s downloadStatus) String() string {
+	switch s {
+	case downloadQueued:
+		return "queued"
+	case downloadActive:
+		return "downloading"
+	case downloadDone:
+		return "done"
+	case downloadFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+type DownloadJob struct {
+	ID        int
+	Database  string
+	Accession string
+	Version   string
+	Ids       []string
+	// UpdateDates holds each record's last update, in the order of Ids
+	UpdateDates []string
+	Format      DownloadFormat
+	Compress    Compression
+	Level       int
+	Path        string
+	Regions     []BEDRecord // set for extractions, one FASTA record per interval
+	Estimate    int64       // expected size, used when the server sends no length
+	Written     int64
+	Total       int64
+	Status      downloadStatus
+	Err         error
+	Started     time.Time
+	Finished    time.Time
+}
+
+// NewDownloadJob creates a job for a single record.
+func NewDownloadJob(database string, seq GBSeq) *DownloadJob {
+	accVer := seq.AccessionVersion
+	if accVer == "" {
+		accVer = seq.PrimaryAccession
+	}
+	acc, ver := SplitAccessionVersion(accVer)
+	return &DownloadJob{
+		Database:    database,
+		Accession:   acc,
+		Version:     ver,
+		Ids:         []string{accVer},
+		UpdateDates: []string{seq.UpdateDate},
+		Estimate:    estimateSize(seq.Length),
+	}
+}
+
+// estimateSize guesses the size of a record of the given length, or 0 when
+// the length isn't known.
+func estimateSize(length int) int64 {
+	if length <= 0 {
+		return 0
+	}
+	return int64(length + length/60 + 200)
+}
+
+func SplitAccessionVersion(accVer string) (string, string) {
+	acc, ver, _ := strings.Cut(accVer, ".")
+	return acc, ver
+}
+
+func (job *DownloadJob) Percent() float64 {
+	switch job.Status {
+	case downloadDone:
+		return 1
+	case downloadQueued:
+		return 0
+	}
+	total := job.Total
+	if total <= 0 {
+		total = job.Estimate
+	}
+	if total <= 0 {
+		return 0
+	}
+	// never claim to be finished before we are
+	return min(float64(job.Written)/float64(total), 0.99)
+}
+
+var efetchURL = "https://eutils.ncbi.nlm.nih.gov/entrez/eutils/efetch.fcgi"
+
+func (job *DownloadJob) Params() url.Values {
+	params := url.Values{}
+	params.Add("db", job.Database)
+	params.Add("id", strings.Join(job.Ids, ","))
+	params.Add("rettype", job.Format.RetType)
+	params.Add("retmode", job.Format.RetMode)
+	return params
+}
+
+func (job *DownloadJob) URL() string {
+	return efetchURL + "?" + job.Params().Encode()
+}
+
+// request issues the EFetch call. Batches are POSTed, since a few hundred ids
+// don't fit in a URL.
+func (job *DownloadJob) request() (*http.Response, error) {
+	if len(job.Ids) > 1 {
+		return http.PostForm(efetchURL, job.Params())
+	}
+	return http.Get(job.URL())
+}
+
+// messages sent from a running download back to the model
+type downloadProgressMsg struct {
+	ID      int
+	Written int64
+	Total   int64
+}
+
+type downloadDoneMsg struct {
+	ID  int
+	Err error
+}
+
+type DownloadManager struct {
+	Dir       string
+	Template  string
+	Format    int // index into DownloadFormats
+	Overwrite bool
+	Compress  Compression
+	Level     int // gzip level, 1-9
+	Jobs      []*DownloadJob
+	Bar       progress.Model
+
+	active  *DownloadJob
+	nextID  int
+	updates chan tea.Msg
+}
+
+var DefaultFilenameTemplate = "{accession}.{version}.{ext}"
+
+func NewDownloadManager() *DownloadManager {
+	dir := os.Getenv("BIODATA_DOWNLOAD_DIR")
+	if dir == "" {
+		dir = "."
+	}
+	return &DownloadManager{
+		Dir:      dir,
+		Template: DefaultFilenameTemplate,
+		Level:    6,
+		Bar:      progress.New(progress.WithDefaultGradient(), progress.WithWidth(30)),
+		updates:  make(chan tea.Msg, 16),
+	}
+}
+
+// Filename expands the manager's template for a job. Supported fields are
+// {accession}, {version}, {ext}, {db} and {format}.
+func (dm *DownloadManager) Filename(job *DownloadJob) string {
+	r := strings.NewReplacer(
+		"{accession}", job.Accession,
+		"{version}", job.Version,
+		"{ext}", job.Format.Ext+job.Compress.Suffix(),
+		"{db}", job.Database,
+		"{format}", job.Format.Name,
+	)
+	name := r.Replace(dm.Template)
+	// an empty field shouldn't leave a doubled separator behind
+	for strings.Contains(name, "..") {
+		name = strings.ReplaceAll(name, "..", ".")
+	}
+	return filepath.Join(dm.Dir, strings.Trim(name, "."))
+}
+
+// Enqueue adds a job using the manager's current format (unless the job
+// already has one) and destination, and starts it if nothing else is running.
+func (dm *DownloadManager) Enqueue(job *DownloadJob) tea.Cmd {
+	dm.nextID++
+	job.ID = dm.nextID
+	if job.Format.Name == "" {
+		job.Format = DownloadFormats[dm.Format]
+	}
+	job.Compress = dm.Compress
+	job.Level = dm.Level
+	job.Path = dm.Filename(job)
+	job.Status = downloadQueued
+	dm.Jobs = append(dm.Jobs, job)
+
+	if dm.active != nil {
+		return nil
+	}
+	return dm.startNext()
+}
+
+func (dm *DownloadManager) startNext() tea.Cmd {
+	for _, job := range dm.Jobs {
+		if job.Status != downloadQueued {
+			continue
+		}
+		if _, err := os.Stat(job.Path); err == nil && !dm.Overwrite {
+			job.Status = downloadFailed
+			job.Err = fmt.Errorf("%s already exists", job.Path)
+			continue
+		}
+		job.Status = downloadActive
+		job.Started = time.Now()
+		dm.active = job
+		go dm.run(job)
+		return dm.wait()
+	}
+	return nil
+}
+
+func (dm *DownloadManager) wait() tea.Cmd {
+	return func() tea.Msg {
+		return <-dm.updates
+	}
+}
+
+func (dm *DownloadManager) find(id int) *DownloadJob {
+	for _, job := range dm.Jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
+func (dm *DownloadManager) Progress(msg downloadProgressMsg) tea.Cmd {
+	if job := dm.find(msg.ID); job != nil {
+		job.Written = msg.Written
+		job.Total = msg.Total
+	}
+	return dm.wait()
+}
+
+func (dm *DownloadManager) Done(msg downloadDoneMsg) tea.Cmd {
+	if job := dm.find(msg.ID); job != nil {
+		job.Finished = time.Now()
+		job.Err = msg.Err
+		if msg.Err != nil {
+			job.Status = downloadFailed
+		} else {
+			job.Status = downloadDone
+		}
+	}
+	dm.active = nil
+	return dm.startNext()
+}
+
+// run streams a job to disk. It is the only goroutine touching the file, and
+// reports back to the model exclusively through dm.updates.
+func (dm *DownloadManager) run(job *DownloadJob) {
+	err := dm.fetch(job)
+	dm.updates <- downloadDoneMsg{ID: job.ID, Err: err}
+}
+
+func (dm *DownloadManager) fetch(job *DownloadJob) error {
+	if err := os.MkdirAll(filepath.Dir(job.Path), 0755); err != nil {
+		return err
+	}
+
+	// write to a temporary file so a failed download never looks complete
+	tmp := job.Path + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	// compress while streaming; progress counts uncompressed bytes and the
+	// checksum covers exactly what lands on disk
+	hash := sha256.New()
+	out := &countingWriter{w: io.MultiWriter(f, hash)}
+	zw, err := job.Compress.NewWriter(out, job.Level)
+	if err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	pw := &progressWriter{id: job.ID, updates: dm.updates}
+	if len(job.Regions) > 0 {
+		err = copyRegions(io.MultiWriter(zw, pw), job)
+	} else {
+		err = copyRecords(io.MultiWriter(zw, pw), job, pw)
+	}
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && pw.written == 0 {
+		err = errors.New("empty response")
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	// the sidecar goes first, so a file in place always has its manifest
+	if err := WriteProvenance(job.Path, NewProvenance(job, out.n, hash.Sum(nil))); err != nil {
+		os.Remove(tmp)
+		os.Remove(job.Path + ProvenanceSuffix)
+		return err
+	}
+	if err := os.Rename(tmp, job.Path); err != nil {
+		os.Remove(tmp)
+		os.Remove(job.Path + ProvenanceSuffix)
+		return err
+	}
+	return nil
+}
+
+// copyRecords streams the job's single EFetch request into w.
+func copyRecords(w io.Writer, job *DownloadJob, pw *progressWriter) error {
+	resp, err := job.request()
+	if err != nil {
+		return fmt.Errorf("failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("efetch returned %s", resp.Status)
+	}
+	pw.total = resp.ContentLength
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// regionDelay keeps batch extraction under NCBI's three requests a second.
+var regionDelay = 350 * time.Millisecond
+
+// RegionParams builds the EFetch parameters that cut one interval out of a
+// record as FASTA.
+func RegionParams(database string, rec BEDRecord) url.Values {
+	strand := "1"
+	if rec.Strand == "-" {
+		strand = "2"
+	}
+	params := url.Values{}
+	params.Add("db", database)
+	params.Add("id", rec.Chrom)
+	params.Add("rettype", "fasta")
+	params.Add("retmode", "text")
+	params.Add("seq_start", fmt.Sprint(rec.Start+1))
+	params.Add("seq_stop", fmt.Sprint(rec.End))
+	params.Add("strand", strand)
+	return params
+}
+
+// copyRegions fetches every interval in turn and writes it out as FASTA,
+// named after the interval rather than the source record.
+func copyRegions(w io.Writer, job *DownloadJob) error {
+	for i, rec := range job.Regions {
+		if i > 0 {
+			time.Sleep(regionDelay)
+		}
+		if rec.End <= rec.Start {
+			return fmt.Errorf("%s is empty", rec.Label())
+		}
+		resp, err := http.Get(efetchURL + "?" + RegionParams(job.Database, rec).Encode())
+		if err != nil {
+			return fmt.Errorf("failed to make request: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return fmt.Errorf("%s: efetch returned %s", rec.Label(), resp.Status)
+		}
+		got, err := NewFastaReader(resp.Body).Read()
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", rec.Label(), err)
+		}
+
+		desc := fmt.Sprintf("%s:%d-%d", rec.Chrom, rec.Start+1, rec.End)
+		if rec.Strand == "-" {
+			desc += " (-)"
+		}
+		out := FastaRecord{ID: rec.Label(), Description: desc, Sequence: got.Sequence}
+		if err := WriteFasta(w, []FastaRecord{out}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// progressWriter counts bytes and sends throttled progress updates.
+type progressWriter struct {
+	id      int
+	written int64
+	total   int64
+	last    time.Time
+	updates chan<- tea.Msg
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	pw.written += int64(len(p))
+	if time.Since(pw.last) > 100*time.Millisecond {
+		pw.last = time.Now()
+		pw.updates <- downloadProgressMsg{ID: pw.id, Written: pw.written, Total: pw.total}
+	}
+	return len(p), nil
+}
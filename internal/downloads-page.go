@@ -0,0 +1,222 @@
+package internal
+
+import (
+	"compress/gzip"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	faintStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	doneStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+)
+
+type downloadsPage struct {
+	Title    string
+	Dir      textinput.Model
+	Template textinput.Model
+	Editing  bool
+}
+
+func NewDownloadsPage() *downloadsPage {
+	dir := textinput.New()
+	dir.Prompt = "Directory: "
+	dir.CharLimit = 256
+	dir.Width = 40
+
+	tpl := textinput.New()
+	tpl.Prompt = "Filename:  "
+	tpl.CharLimit = 128
+	tpl.Width = 40
+
+	return &downloadsPage{
+		Title:    "Downloads",
+		Dir:      dir,
+		Template: tpl,
+	}
+}
+
+// UpdatePage implements page.
+func (page *downloadsPage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
+	dm := m.Downloads
+
+	if page.Editing {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			switch msg.String() {
+			case "tab", "shift+tab", "up", "down":
+				if page.Dir.Focused() {
+					page.Dir.Blur()
+					page.Template.Focus()
+				} else {
+					page.Template.Blur()
+					page.Dir.Focus()
+				}
+				return m, nil
+			case "enter":
+				if v := strings.TrimSpace(page.Dir.Value()); v != "" {
+					dm.Dir = v
+				}
+				if v := strings.TrimSpace(page.Template.Value()); v != "" {
+					dm.Template = v
+				}
+				page.stopEditing()
+				return m, nil
+			case "backspace":
+				if page.Dir.Value() == "" && page.Template.Value() == "" {
+					page.stopEditing()
+					return m, nil
+				}
+			}
+		}
+
+		var cmds [2]tea.Cmd
+		page.Dir, cmds[0] = page.Dir.Update(msg)
+		page.Template, cmds[1] = page.Template.Update(msg)
+		return m, tea.Batch(cmds[:]...)
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "s":
+			page.Editing = true
+			page.Dir.SetValue(dm.Dir)
+			page.Template.SetValue(dm.Template)
+			page.Dir.Focus()
+			return m, nil
+		case "f":
+			dm.Format = (dm.Format + 1) % len(DownloadFormats)
+		case "o":
+			dm.Overwrite = !dm.Overwrite
+		case "z":
+			dm.Compress = Compressions[(int(dm.Compress)+1)%len(Compressions)]
+		case "+", "=":
+			dm.Level = min(dm.Level+1, gzip.BestCompression)
+		case "-":
+			dm.Level = max(dm.Level-1, gzip.BestSpeed)
+		case "c":
+			// clear finished jobs
+			jobs := dm.Jobs[:0]
+			for _, job := range dm.Jobs {
+				if job.Status == downloadQueued || job.Status == downloadActive {
+					jobs = append(jobs, job)
+				}
+			}
+			dm.Jobs = jobs
+		}
+		m.UpdateBack(msg)
+	}
+	return m, nil
+}
+
+func (page *downloadsPage) stopEditing() {
+	page.Editing = false
+	page.Dir.Blur()
+	page.Template.Blur()
+}
+
+func (page *downloadsPage) settingsView(dm *DownloadManager) string {
+	if page.Editing {
+		return page.Dir.View() + "\n" + page.Template.View() + "\n" +
+			faintStyle.Render("tab: switch field • enter: save")
+	}
+	overwrite := "no"
+	if dm.Overwrite {
+		overwrite = "yes"
+	}
+	s := fmt.Sprintf("Directory: %s\n", dm.Dir)
+	s += fmt.Sprintf("Filename:  %s\n", dm.Template)
+	s += fmt.Sprintf("Format:    %s\n", DownloadFormats[dm.Format].Name)
+	s += fmt.Sprintf("Overwrite: %s\n", overwrite)
+	if dm.Compress == CompressNone {
+		s += "Compress:  none\n"
+	} else {
+		s += fmt.Sprintf("Compress:  %s, level %d\n", dm.Compress, dm.Level)
+	}
+	s += faintStyle.Render("s: edit destination • f: cycle format • o: toggle overwrite • z: cycle compression • +/-: level • c: clear finished")
+	return s
+}
+
+func jobView(dm *DownloadManager, job *DownloadJob) string {
+	name := job.Accession
+	if job.Version != "" {
+		name += "." + job.Version
+	}
+	if len(job.Ids) > 1 {
+		name = fmt.Sprintf("%d records", len(job.Ids))
+	}
+	if len(job.Regions) > 0 {
+		name = fmt.Sprintf("%d regions", len(job.Regions))
+	}
+	line := fmt.Sprintf("%-20s %-12s ", name, job.Status)
+	switch job.Status {
+	case downloadActive:
+		if job.Total <= 0 && job.Estimate <= 0 {
+			// no idea how big it is, so just count
+			line += faintStyle.Render(humanBytes(job.Written) + " so far")
+			break
+		}
+		line += dm.Bar.ViewAs(job.Percent())
+	case downloadQueued:
+		line += dm.Bar.ViewAs(job.Percent())
+	case downloadDone:
+		line += doneStyle.Render(fmt.Sprintf("%s (%s)", job.Path, humanBytes(job.Written)))
+	case downloadFailed:
+		line += errorStyle.Render(job.Err.Error())
+	}
+	return line
+}
+
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Page implements page.
+func (page *downloadsPage) Page(m Model) string {
+	dm := m.Downloads
+	p := page.settingsView(dm) + "\n\n"
+
+	var active, finished []string
+	for _, job := range dm.Jobs {
+		if job.Status == downloadQueued || job.Status == downloadActive {
+			active = append(active, jobView(dm, job))
+		} else {
+			finished = append(finished, jobView(dm, job))
+		}
+	}
+
+	p += "--- ACTIVE ---\n"
+	if len(active) == 0 {
+		p += faintStyle.Render("nothing downloading") + "\n"
+	}
+	for _, line := range active {
+		p += line + "\n"
+	}
+
+	p += "\n--- COMPLETED ---\n"
+	if len(finished) == 0 {
+		p += faintStyle.Render("no finished downloads") + "\n"
+	}
+	for _, line := range finished {
+		p += line + "\n"
+	}
+	return p + "\n"
+}
+
+func (page *downloadsPage) GetTitle() string {
+	return page.Title
+}
@@ -0,0 +1,426 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// EMBL and GenBank name some molecule types and divisions differently.
+var (
+	emblMolTypes = map[string]string{
+		"genomic DNA":    "DNA",
+		"genomic RNA":    "RNA",
+		"other DNA":      "DNA",
+		"unassigned DNA": "DNA",
+		"other RNA":      "RNA",
+		"unassigned RNA": "RNA",
+		"viral cRNA":     "cRNA",
+		"protein":        "AA",
+	}
+	genbankMolTypes = map[string]string{
+		"DNA": "genomic DNA",
+		"RNA": "genomic RNA",
+		"AA":  "protein",
+	}
+	emblDivisions = map[string]string{
+		"HUM": "PRI", "MUS": "ROD", "FUN": "PLN", "PRO": "BCT",
+		"UNC": "UNA", "TGN": "SYN",
+	}
+	genbankDivisions = map[string]string{
+		"BCT": "PRO", "UNA": "UNC",
+	}
+)
+
+// EMBLReader parses EMBL flat files, as served by ENA, into GBSeq.
+type EMBLReader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func NewEMBLReader(r io.Reader) *EMBLReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 1<<16), 1<<24)
+	return &EMBLReader{s: s}
+}
+
+func (er *EMBLReader) scan() (string, string, bool) {
+	if !er.s.Scan() {
+		return "", "", false
+	}
+	er.line++
+	line := strings.TrimRight(er.s.Text(), "\r")
+	if len(line) < 2 {
+		return line, "", true
+	}
+	code := line[:2]
+	if len(line) > 5 {
+		return code, line[5:], true
+	}
+	return code, "", true
+}
+
+// Read returns the next record, or io.EOF when there are none left.
+func (er *EMBLReader) Read() (GBSeq, error) {
+	var seq GBSeq
+
+	// find ID
+	for {
+		code, rest, ok := er.scan()
+		if !ok {
+			if err := er.s.Err(); err != nil {
+				return seq, err
+			}
+			return seq, io.EOF
+		}
+		if strings.TrimSpace(code) == "" {
+			continue
+		}
+		if code != "ID" {
+			return seq, fmt.Errorf("line %d: expected ID", er.line)
+		}
+		parseEMBLID(&seq, rest)
+		break
+	}
+
+	var field genbankField
+	flush := func() {
+		if field.key != "" {
+			applyEMBLField(&seq, field)
+		}
+		field = genbankField{}
+	}
+
+	var fb *featureBuilder
+	var sb strings.Builder
+	for {
+		code, rest, ok := er.scan()
+		if !ok {
+			if err := er.s.Err(); err != nil {
+				return seq, err
+			}
+			return seq, fmt.Errorf("line %d: record %s is missing its closing //", er.line, seq.Locus)
+		}
+
+		switch code {
+		case "//":
+			flush()
+			if fb != nil {
+				seq.Features = append(seq.Features, fb.feature())
+			}
+			seq.Sequence = sb.String()
+			if seq.Length == 0 {
+				seq.Length = len(seq.Sequence)
+			}
+			return seq, nil
+		case "  ":
+			// sequence lines carry no code
+			sb.WriteString(stripSequenceLine(rest))
+		case "FT":
+			flush()
+			if len(rest) > 0 && rest[0] != ' ' {
+				if fb != nil {
+					seq.Features = append(seq.Features, fb.feature())
+				}
+				key, loc, _ := strings.Cut(rest, " ")
+				fb = &featureBuilder{key: key, location: strings.TrimSpace(loc)}
+			} else if fb != nil {
+				fb.add(strings.TrimSpace(rest))
+			}
+		case "", "XX", "FH", "SQ":
+			flush()
+		default:
+			// each RN starts a new reference, other codes just continue
+			if code != field.key || code == "RN" {
+				flush()
+				field.key = code
+			}
+			field.lines = append(field.lines, strings.TrimSpace(rest))
+		}
+	}
+}
+
+// Version returns the sequence version, taken from the accession.version.
+func (seq GBSeq) Version() string {
+	_, ver := SplitAccessionVersion(seq.AccessionVersion)
+	return ver
+}
+
+// parseEMBLID reads "X56734; SV 1; linear; mRNA; STD; PLN; 1859 BP."
+func parseEMBLID(seq *GBSeq, rest string) {
+	parts := strings.Split(strings.TrimSuffix(strings.TrimSpace(rest), "."), ";")
+	for idx := range parts {
+		parts[idx] = strings.TrimSpace(parts[idx])
+	}
+	seq.Locus = parts[0]
+	if len(parts) < 7 {
+		// pre-2006 layout, "AA03518 standard; DNA; FUN; 237 BP."
+		seq.Locus = strings.Fields(parts[0])[0]
+		for _, p := range parts[1:] {
+			if n, unit, ok := strings.Cut(p, " "); ok && (unit == "BP" || unit == "AA") {
+				seq.Length, _ = strconv.Atoi(n)
+			} else if len(p) == 3 {
+				seq.Division = mapOr(emblDivisions, p)
+			} else {
+				seq.MolType = mapOr(emblMolTypes, p)
+			}
+		}
+		return
+	}
+	if ver, ok := strings.CutPrefix(parts[1], "SV "); ok {
+		seq.AccessionVersion = parts[0] + "." + ver
+	}
+	seq.Topology = parts[2]
+	seq.MolType = mapOr(emblMolTypes, parts[3])
+	seq.Division = mapOr(emblDivisions, parts[5])
+	n, _, _ := strings.Cut(parts[6], " ")
+	seq.Length, _ = strconv.Atoi(n)
+}
+
+func mapOr(m map[string]string, key string) string {
+	if v, ok := m[key]; ok {
+		return v
+	}
+	return key
+}
+
+func applyEMBLField(seq *GBSeq, f genbankField) {
+	text := f.text()
+	switch f.key {
+	case "AC":
+		if acc, _, _ := strings.Cut(text, ";"); acc != "" {
+			seq.PrimaryAccession = strings.TrimSpace(acc)
+		}
+	case "DT":
+		for _, line := range f.lines {
+			date, _, _ := strings.Cut(line, " ")
+			if strings.Contains(line, "Created") {
+				seq.CreationDate = date
+			} else {
+				seq.UpdateDate = date
+			}
+		}
+	case "DE":
+		seq.Definition = strings.TrimSuffix(text, ".")
+	case "KW":
+		seq.Keywords = splitKeywords(text)
+	case "OS":
+		seq.Source = text
+		seq.Organism, _, _ = strings.Cut(text, " (")
+	case "OC":
+		seq.Taxonomy = strings.TrimSuffix(text, ".")
+	case "CC":
+		seq.Comment = text
+	case "RN":
+		n := strings.Trim(text, "[] ")
+		ref := GBRef{}
+		ref.RefNumber, _ = strconv.Atoi(n)
+		seq.References = append(seq.References, ref)
+	case "RP", "RX", "RA", "RG", "RT", "RL":
+		if len(seq.References) == 0 {
+			return
+		}
+		ref := &seq.References[len(seq.References)-1]
+		switch f.key {
+		case "RP":
+			ref.Position = strings.ReplaceAll(strings.ReplaceAll(text, "-", ".."), ", ", "; ")
+		case "RX":
+			for _, line := range f.lines {
+				if id, ok := strings.CutPrefix(line, "PUBMED; "); ok {
+					ref.PubMed = strings.TrimSuffix(id, ".")
+				}
+			}
+		case "RA":
+			for _, a := range strings.Split(strings.TrimSuffix(text, ";"), ",") {
+				if a = strings.TrimSpace(a); a != "" {
+					ref.Authors = append(ref.Authors, emblToGenBankAuthor(a))
+				}
+			}
+		case "RG":
+			ref.Authors = append(ref.Authors, strings.TrimSuffix(text, ";"))
+		case "RT":
+			ref.Title = strings.Trim(strings.TrimSuffix(text, ";"), `"`)
+		case "RL":
+			ref.Journal = strings.TrimSuffix(text, ".")
+		}
+	}
+}
+
+// "Oxtoby E." in EMBL is "Oxtoby,E." in GenBank
+func emblToGenBankAuthor(a string) string {
+	idx := strings.LastIndex(a, " ")
+	if idx < 0 {
+		return a
+	}
+	return a[:idx] + "," + a[idx+1:]
+}
+
+func genbankToEMBLAuthor(a string) string {
+	return strings.Replace(a, ",", " ", 1)
+}
+
+// writeEMBLField writes a two letter line code and its value wrapped to 80
+// columns.
+func writeEMBLField(w io.Writer, code, value string) {
+	for _, line := range wrapText(value, 75, " ") {
+		fmt.Fprintf(w, "%-5s%s\n", code, line)
+	}
+}
+
+// WriteEMBL writes a record as an EMBL flat file entry.
+func WriteEMBL(w io.Writer, seq GBSeq) error {
+	bw := bufio.NewWriter(w)
+
+	unit, mol := "BP", mapOr(genbankMolTypes, seq.MolType)
+	if IsProtein(seq) {
+		unit = "AA"
+	}
+	for _, f := range seq.Features {
+		if v, ok := f.Qualifier("mol_type"); ok && f.Key == "source" {
+			mol = v
+		}
+	}
+	topology := seq.Topology
+	if topology == "" {
+		topology = "linear"
+	}
+	sv := seq.Version()
+	if sv == "" {
+		sv = "1"
+	}
+	fmt.Fprintf(bw, "ID   %s; SV %s; %s; %s; STD; %s; %d %s.\n",
+		seq.PrimaryAccession, sv, topology, mol, emblDivision(seq), seq.Length, unit)
+	fmt.Fprintln(bw, "XX")
+	fmt.Fprintf(bw, "AC   %s;\n", seq.PrimaryAccession)
+	fmt.Fprintln(bw, "XX")
+	if seq.CreationDate != "" || seq.UpdateDate != "" {
+		if seq.CreationDate != "" {
+			fmt.Fprintf(bw, "DT   %s (Created)\n", seq.CreationDate)
+		}
+		if seq.UpdateDate != "" {
+			fmt.Fprintf(bw, "DT   %s (Last updated, Version %s)\n", seq.UpdateDate, sv)
+		}
+		fmt.Fprintln(bw, "XX")
+	}
+	writeEMBLField(bw, "DE", seq.Definition)
+	fmt.Fprintln(bw, "XX")
+	keywords := "."
+	if len(seq.Keywords) > 0 {
+		keywords = strings.Join(seq.Keywords, "; ") + "."
+	}
+	writeEMBLField(bw, "KW", keywords)
+	fmt.Fprintln(bw, "XX")
+	source := seq.Source
+	if source == "" {
+		source = seq.Organism
+	}
+	writeEMBLField(bw, "OS", source)
+	if seq.Taxonomy != "" {
+		writeEMBLField(bw, "OC", seq.Taxonomy+".")
+	}
+	fmt.Fprintln(bw, "XX")
+
+	for _, ref := range seq.References {
+		fmt.Fprintf(bw, "RN   [%d]\n", ref.RefNumber)
+		if ref.Position != "" {
+			fmt.Fprintf(bw, "RP   %s\n", strings.ReplaceAll(strings.ReplaceAll(ref.Position, "..", "-"), "; ", ", "))
+		}
+		if ref.PubMed != "" {
+			fmt.Fprintf(bw, "RX   PUBMED; %s.\n", ref.PubMed)
+		}
+		if len(ref.Authors) > 0 {
+			authors := make([]string, len(ref.Authors))
+			for idx, a := range ref.Authors {
+				authors[idx] = genbankToEMBLAuthor(a)
+			}
+			writeEMBLField(bw, "RA", strings.Join(authors, ", ")+";")
+		}
+		if ref.Title != "" {
+			writeEMBLField(bw, "RT", `"`+ref.Title+`";`)
+		} else {
+			fmt.Fprintln(bw, "RT   ;")
+		}
+		if ref.Journal != "" {
+			writeEMBLField(bw, "RL", ref.Journal+".")
+		}
+		fmt.Fprintln(bw, "XX")
+	}
+	if seq.Comment != "" {
+		writeEMBLField(bw, "CC", seq.Comment)
+		fmt.Fprintln(bw, "XX")
+	}
+
+	if len(seq.Features) > 0 {
+		fmt.Fprintln(bw, "FH   Key             Location/Qualifiers")
+		fmt.Fprintln(bw, "FH")
+		writeFeatureTable(bw, seq.Features, "FT   ")
+		fmt.Fprintln(bw, "XX")
+	}
+
+	writeEMBLSequence(bw, seq, unit)
+	fmt.Fprintln(bw, "//")
+	return bw.Flush()
+}
+
+func emblDivision(seq GBSeq) string {
+	switch seq.Division {
+	case "PRI":
+		if seq.Organism == "Homo sapiens" {
+			return "HUM"
+		}
+		return "MAM"
+	case "ROD":
+		if seq.Organism == "Mus musculus" {
+			return "MUS"
+		}
+	case "":
+		return "UNC"
+	}
+	return mapOr(genbankDivisions, seq.Division)
+}
+
+func writeEMBLSequence(w io.Writer, seq GBSeq, unit string) {
+	s := strings.ToLower(seq.Sequence)
+	if unit == "AA" {
+		fmt.Fprintf(w, "SQ   Sequence %d AA;\n", len(s))
+	} else {
+		counts := map[byte]int{}
+		for i := 0; i < len(s); i++ {
+			counts[s[i]]++
+		}
+		other := len(s) - counts['a'] - counts['c'] - counts['g'] - counts['t']
+		fmt.Fprintf(w, "SQ   Sequence %d BP; %d A; %d C; %d G; %d T; %d other;\n",
+			len(s), counts['a'], counts['c'], counts['g'], counts['t'], other)
+	}
+	for i := 0; i < len(s); i += 60 {
+		var blocks []string
+		for j := i; j < min(i+60, len(s)); j += 10 {
+			blocks = append(blocks, s[j:min(j+10, len(s))])
+		}
+		fmt.Fprintf(w, "     %-65s%10d\n", strings.Join(blocks, " "), min(i+60, len(s)))
+	}
+}
+
+// ReadEMBLFile reads every record in an EMBL flat file, which may be gzipped.
+func ReadEMBLFile(path string) ([]GBSeq, error) {
+	f, err := OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var seqs []GBSeq
+	er := NewEMBLReader(f)
+	for {
+		seq, err := er.Read()
+		if err == io.EOF {
+			return seqs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		seqs = append(seqs, seq)
+	}
+}
@@ -22,6 +22,7 @@ type entrezPage struct {
 	Spinner     spinner.Model
 	Loading     bool
 	Received    bool
+	Status      string
 }
 
 func NewEntrezPage(title, filter, desc string) *entrezPage {
@@ -62,16 +63,25 @@ func seqSummaryItem(id string, seq GBSeq) ListItem {
 func SeqsToItems(ids []string, seqs []GBSeq) []list.Item {
 	out := make([]list.Item, len(seqs))
 	for idx, seq := range seqs {
-		out[idx] = seqSummaryItem(ids[idx], seq)
+		item := seqSummaryItem(ids[idx], seq)
+		item.index = idx
+		out[idx] = item
 	}
 	return out
 }
 
 type ListItem struct {
 	title, desc string
+	marked      bool
+	index       int // position in the unfiltered list
 }
 
-func (i ListItem) Title() string       { return i.title }
+func (i ListItem) Title() string {
+	if i.marked {
+		return "✓ " + i.title
+	}
+	return i.title
+}
 func (i ListItem) Description() string { return i.desc }
 func (i ListItem) FilterValue() string { return i.title }
 
@@ -97,6 +107,12 @@ func fetch(filter, query string) func() tea.Msg {
 		if err != nil {
 			return errMsg{err: err}
 		}
+		// the records only hold one base, so ask for their real lengths;
+		// without them the length stays unknown
+		lengths, _ := ESummaryLengths("nuccore", ids)
+		for i := range res {
+			res[i].Length = lengths[res[i].AccessionVersion]
+		}
 		return entrezMsg{
 			result: res,
 			ids:    ids,
@@ -125,6 +141,13 @@ func (page *entrezPage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
 					fetch(page.Filter, page.Input.Value()),
 				)
 			}
+		case key.Matches(msg, m.Keys.Pick) && page.Received && page.Results.SelectedItem() != nil &&
+			itemIndex(&page.Results) < len(page.Response):
+			page.Status = "picking for alignment ..."
+			return m, pickForAlignment("nuccore", page.Response[itemIndex(&page.Results)], false)
+		case msg.String() == "u" && page.Received && page.Results.FilterState() != list.Filtering && len(page.Response) > 0:
+			page.Status = "counting codons in all results ..."
+			return m, countCodons("nuccore", page.Results.Title, page.Response, false)
 		case key.Matches(msg, m.Keys.Back):
 			if page.Input.Value() == "" && !page.Received {
 				m.UpdateBack(msg)
@@ -142,7 +165,10 @@ func (page *entrezPage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
 
 		// generate pages for all responses
 		for idx, res := range page.Response {
-			m.Pages[pageStart+idx] = NewSeqResPage(res, msg.ids[idx], res.PrimaryAccession, m.Width-20, m.Height-8)
+			rec := NewSeqResPage(res, msg.ids[idx], res.PrimaryAccession, m.Width-20, m.Height-8)
+			rec.Partial = true
+			rec.refresh()
+			m.Pages[pageStart+idx] = rec
 		}
 
 		// customize delegate
@@ -152,13 +178,35 @@ func (page *entrezPage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
 		page.Results.Title = msg.query
 		m.ShowHelp = false
 		page.Received = true
+		page.syncMarks(m)
+	case alignPickedMsg:
+		page.Status = pickedStatus(msg)
+		return m, nil
+
+	case codonUsageMsg:
+		page.Status = ""
+		if err := openCodonPage(&m, msg, page.Title); err != nil {
+			page.Status = "codon usage failed: " + err.Error()
+		}
+		return m, nil
+
 	case errMsg:
 		log.Fatalf("error searching or fetching in Entrez: %s", msg.err)
 
 	case listSelectMsg:
 		m.ShowHelp = false
 		m.UpdateHistory(m.Page, page.Title)
-		m.Page = pageStart + msg.Index
+		m.Page = pageStart + itemIndex(&page.Results)
+
+	case listMarkMsg:
+		// msg.Index counts only the items left by a filter
+		idx := itemIndex(&page.Results)
+		if idx >= len(page.Response) {
+			break
+		}
+		item := page.Results.Items()[idx].(ListItem)
+		item.marked = m.Cart.Toggle(CartItemFromSeq("nuccore", page.Response[idx]))
+		return m, page.Results.SetItem(idx, item)
 
 	case tea.WindowSizeMsg:
 		if page.Received {
@@ -183,6 +231,10 @@ type listSelectMsg struct {
 	Index int
 }
 
+type listMarkMsg struct {
+	Index int
+}
+
 func UpdateDelegate(msg tea.Msg, m *list.Model) tea.Cmd {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
@@ -197,17 +249,61 @@ func UpdateDelegate(msg tea.Msg, m *list.Model) tea.Cmd {
 					Index: selectedIndex,
 				}
 			}
+		case " ":
+			if m.SelectedItem() == nil || m.FilterState() == list.Filtering {
+				return nil
+			}
+			selectedIndex := m.Index()
+			return func() tea.Msg {
+				return listMarkMsg{
+					Index: selectedIndex,
+				}
+			}
 		}
 	}
 	return nil
 }
 
+// itemIndex is the selected item's position in the whole list, which differs
+// from m.Index() while a filter is applied.
+func itemIndex(m *list.Model) int {
+	if item, ok := m.SelectedItem().(ListItem); ok {
+		return item.index
+	}
+	return m.Index()
+}
+
+// syncMarks ticks the results that are in the cart, which may have changed
+// on the cart page since they were last shown.
+func (page *entrezPage) syncMarks(m Model) {
+	items := page.Results.Items()
+	for idx, res := range page.Response {
+		if idx >= len(items) {
+			break
+		}
+		item := items[idx].(ListItem)
+		marked := m.Cart.Contains(CartItemFromSeq("nuccore", res))
+		if item.marked == marked {
+			continue
+		}
+		item.marked = marked
+		// a filtered list keeps its own copies, so refilter straight away
+		if cmd := page.Results.SetItem(idx, item); cmd != nil {
+			page.Results, _ = page.Results.Update(cmd())
+		}
+	}
+}
+
 func (page *entrezPage) Page(m Model) string {
 	p := page.Description + "\n"
 	p += "\n\n"
 
 	if page.Received {
+		page.syncMarks(m)
 		p += page.Results.View()
+		if page.Status != "" {
+			p += "\n" + faintStyle.Render(page.Status)
+		}
 	} else if page.Loading {
 		p += page.Spinner.View() + " Loading results of query ... "
 	} else {
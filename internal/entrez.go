@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io"
@@ -70,21 +71,25 @@ type GBSet struct {
 }
 
 type GBSeq struct {
-	Locus            string   `xml:"GBSeq_locus"`
-	Length           int      `xml:"GBSeq_length"`
-	StrandType       string   `xml:"GBSeq_strandedness"`
-	MolType          string   `xml:"GBSeq_moltype"`
-	Topology         string   `xml:"GBSeq_topology"`
-	Division         string   `xml:"GBSeq_division"`
-	Definition       string   `xml:"GBSeq_definition"`
-	PrimaryAccession string   `xml:"GBSeq_primary-accession"`
-	CreationDate     string   `xml:"GBSeq_create-date"`
-	UpdateDate       string   `xml:"GBSeq_update-date"`
-	Organism         string   `xml:"GBSeq_organism"`
-	Taxonomy         string   `xml:"GBSeq_taxonomy"`
-	References       []GBRef  `xml:"GBSeq_references>GBReference"`
-	Keywords         []string `xml:"GBSeq_keywords>GBKeyword"`
-	Sequence         string   `xml:"GBSeq_sequence"`
+	Locus            string      `xml:"GBSeq_locus"`
+	Length           int         `xml:"GBSeq_length"`
+	StrandType       string      `xml:"GBSeq_strandedness"`
+	MolType          string      `xml:"GBSeq_moltype"`
+	Topology         string      `xml:"GBSeq_topology"`
+	Division         string      `xml:"GBSeq_division"`
+	Definition       string      `xml:"GBSeq_definition"`
+	PrimaryAccession string      `xml:"GBSeq_primary-accession"`
+	AccessionVersion string      `xml:"GBSeq_accession-version"`
+	CreationDate     string      `xml:"GBSeq_create-date"`
+	UpdateDate       string      `xml:"GBSeq_update-date"`
+	Organism         string      `xml:"GBSeq_organism"`
+	Taxonomy         string      `xml:"GBSeq_taxonomy"`
+	Source           string      `xml:"GBSeq_source"`
+	Comment          string      `xml:"GBSeq_comment"`
+	References       []GBRef     `xml:"GBSeq_references>GBReference"`
+	Keywords         []string    `xml:"GBSeq_keywords>GBKeyword"`
+	Features         []GBFeature `xml:"GBSeq_feature-table>GBFeature"`
+	Sequence         string      `xml:"GBSeq_sequence"`
 }
 
 type GBRef struct {
@@ -93,6 +98,38 @@ type GBRef struct {
 	Journal   string   `xml:"GBReference_journal"`
 	PubMed    string   `xml:"GBReference_pubmed"`
 	RefNumber int      `xml:"GBReference_reference"`
+	Position  string   `xml:"GBReference_position"`
+}
+
+type GBFeature struct {
+	Key       string        `xml:"GBFeature_key"`
+	Location  string        `xml:"GBFeature_location"`
+	Intervals []GBInterval  `xml:"GBFeature_intervals>GBInterval"`
+	Quals     []GBQualifier `xml:"GBFeature_quals>GBQualifier"`
+}
+
+// GBInterval is one span of a feature location. Like NCBI, complement
+// intervals have From > To.
+type GBInterval struct {
+	From      int    `xml:"GBInterval_from"`
+	To        int    `xml:"GBInterval_to"`
+	Point     int    `xml:"GBInterval_point"`
+	Accession string `xml:"GBInterval_accession"`
+}
+
+type GBQualifier struct {
+	Name  string `xml:"GBQualifier_name"`
+	Value string `xml:"GBQualifier_value"`
+}
+
+// Qualifier returns the value of the first qualifier with the given name.
+func (f GBFeature) Qualifier(name string) (string, bool) {
+	for _, q := range f.Quals {
+		if q.Name == name {
+			return q.Value, true
+		}
+	}
+	return "", false
 }
 
 func EFetch(database string, ids []string, wholeSeq bool) ([]GBSeq, error) {
@@ -130,9 +167,52 @@ func EFetch(database string, ids []string, wholeSeq bool) ([]GBSeq, error) {
 	return result.Sequences, nil
 }
 
+// ESummaryLengths looks up the full lengths of records, keyed by
+// accession.version. Search results are fetched with only their first base,
+// so their own GBSeq_length is useless.
+func ESummaryLengths(database string, ids []string) (map[string]int, error) {
+	url := fmt.Sprintf("https://eutils.ncbi.nlm.nih.gov/entrez/eutils/esummary.fcgi?db=%s&id=%s&retmode=json",
+		database, strings.Join(ids, ","))
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Result map[string]json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	lengths := map[string]int{}
+	for uid, raw := range result.Result {
+		if uid == "uids" {
+			continue
+		}
+		var doc struct {
+			AccessionVersion string `json:"accessionversion"`
+			Slen             int    `json:"slen"`
+		}
+		if json.Unmarshal(raw, &doc) == nil && doc.AccessionVersion != "" {
+			lengths[doc.AccessionVersion] = doc.Slen
+		}
+	}
+	return lengths, nil
+}
+
 var LabelPadding = 20
 
-func (seq GBSeq) PrettyPrint() string {
+// PrettyPrint describes the record. Extra sections go just before the
+// sequence.
+func (seq GBSeq) PrettyPrint(extra ...string) string {
 	var sb strings.Builder
 	padding := fmt.Sprintf("%%-%ds", LabelPadding) // creates the %-20s format string dynamically
 
@@ -140,7 +220,9 @@ func (seq GBSeq) PrettyPrint() string {
 	sb.WriteString("\n=== SEQUENCE RECORD ===\n")
 	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Locus:", seq.Locus))
 	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Accession:", seq.PrimaryAccession))
-	// sb.WriteString(fmt.Sprintf(padding+" %d bp\n", "Length:", seq.Length))
+	if seq.Length > 0 {
+		sb.WriteString(fmt.Sprintf(padding+" %d %s\n", "Length:", seq.Length, lengthUnit(seq)))
+	}
 
 	// Sequence characteristics
 	sb.WriteString("\n--- SEQUENCE CHARACTERISTICS ---\n")
@@ -196,10 +278,73 @@ func (seq GBSeq) PrettyPrint() string {
 		}
 	}
 
+	// Features
+	if len(seq.Features) > 0 {
+		blankLine(&sb)
+		sb.WriteString("--- FEATURES ---\n")
+		for _, f := range seq.Features {
+			sb.WriteString(fmt.Sprintf("%-16s %s\n", f.Key, f.Location))
+			for _, q := range f.Quals {
+				value := q.Value
+				if len(value) > 60 {
+					value = value[:57] + "..."
+				}
+				if value == "" {
+					sb.WriteString(fmt.Sprintf("%17s/%s\n", "", q.Name))
+				} else {
+					sb.WriteString(fmt.Sprintf("%17s/%s=%s\n", "", q.Name, value))
+				}
+			}
+		}
+	}
+
 	// Dates
-	sb.WriteString("--- RECORD INFO ---\n")
-	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Created:", seq.CreationDate))
-	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Last Updated:", seq.UpdateDate))
+	if seq.CreationDate != "" || seq.UpdateDate != "" {
+		blankLine(&sb)
+		sb.WriteString("--- RECORD INFO ---\n")
+		sb.WriteString(fmt.Sprintf(padding+" %s\n", "Created:", seq.CreationDate))
+		sb.WriteString(fmt.Sprintf(padding+" %s\n", "Last Updated:", seq.UpdateDate))
+	}
+
+	for _, section := range extra {
+		blankLine(&sb)
+		sb.WriteString(section)
+	}
+
+	// Sequence, GenBank style
+	if seq.Sequence != "" {
+		blankLine(&sb)
+		sb.WriteString("--- SEQUENCE ---\n")
+		sb.WriteString(FormatSequence(seq.Sequence))
+	}
 
 	return strings.TrimSpace(sb.String())
 }
+
+func lengthUnit(seq GBSeq) string {
+	if IsProtein(seq) {
+		return "aa"
+	}
+	return "bp"
+}
+
+// blankLine separates sections, whatever the previous one ended with.
+func blankLine(sb *strings.Builder) {
+	if !strings.HasSuffix(sb.String(), "\n\n") {
+		sb.WriteString("\n")
+	}
+}
+
+// FormatSequence lays a sequence out in numbered lines of six blocks of ten,
+// like the ORIGIN section of a GenBank record.
+func FormatSequence(seq string) string {
+	var sb strings.Builder
+	for i := 0; i < len(seq); i += 60 {
+		sb.WriteString(fmt.Sprintf("%9d", i+1))
+		for j := i; j < min(i+60, len(seq)); j += 10 {
+			sb.WriteString(" " + seq[j:min(j+10, len(seq))])
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
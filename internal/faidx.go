@@ -0,0 +1,281 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// FaiSuffix is appended to a FASTA path to name its index, as samtools does.
+const FaiSuffix = ".fai"
+
+// FaiEntry is one line of a .fai file. Offset is where the first base of the
+// sequence starts; every line but the last holds LineBases bases and takes
+// LineWidth bytes, newline included.
+type FaiEntry struct {
+	Name      string
+	Length    int64
+	Offset    int64
+	LineBases int64
+	LineWidth int64
+}
+
+// BuildFai indexes an uncompressed FASTA file the way samtools faidx does,
+// and fails on the same things: lines of uneven length inside a record and
+// duplicate names.
+func BuildFai(r io.Reader) ([]FaiEntry, error) {
+	br := bufio.NewReaderSize(r, 1<<16)
+	var entries []FaiEntry
+	var cur *FaiEntry
+	seen := map[string]bool{}
+	var offset int64
+	lineNo := 0
+	short := false // a line shorter than LineBases ends the record
+
+	for {
+		line, err := br.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// longer than the buffer; only the length matters past the header
+			rest, err2 := br.ReadBytes('\n')
+			line = append(append([]byte{}, line...), rest...)
+			err = err2
+		}
+		if len(line) == 0 && err == io.EOF {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		lineNo++
+		width := int64(len(line))
+		offset += width
+		text := bytes.TrimRight(line, "\r\n")
+
+		if len(text) > 0 && text[0] == '>' {
+			name, _ := cutName(strings.TrimSpace(string(text[1:])))
+			if seen[name] {
+				return nil, fmt.Errorf("line %d: duplicate sequence name %q", lineNo, name)
+			}
+			seen[name] = true
+			entries = append(entries, FaiEntry{Name: name, Offset: offset})
+			cur = &entries[len(entries)-1]
+			short = false
+			continue
+		}
+		if cur == nil {
+			if len(text) == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: expected a '>' header", lineNo)
+		}
+
+		bases := int64(len(text))
+		if bases == 0 {
+			short = true
+			continue
+		}
+		if short {
+			return nil, fmt.Errorf("line %d: %s has lines of different lengths", lineNo, cur.Name)
+		}
+		if cur.LineBases == 0 {
+			cur.LineBases, cur.LineWidth = bases, width
+		} else if bases > cur.LineBases || (bases == cur.LineBases && width != cur.LineWidth) {
+			return nil, fmt.Errorf("line %d: %s has lines of different lengths", lineNo, cur.Name)
+		}
+		if bases < cur.LineBases {
+			short = true
+		}
+		cur.Length += bases
+	}
+	return entries, nil
+}
+
+// WriteFai writes entries in the five-column .fai layout.
+func WriteFai(w io.Writer, entries []FaiEntry) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range entries {
+		fmt.Fprintf(bw, "%s\t%d\t%d\t%d\t%d\n", e.Name, e.Length, e.Offset, e.LineBases, e.LineWidth)
+	}
+	return bw.Flush()
+}
+
+// ReadFai parses a .fai file. Extra columns (as in FASTQ indexes) are ignored.
+func ReadFai(r io.Reader) ([]FaiEntry, error) {
+	var entries []FaiEntry
+	s := bufio.NewScanner(r)
+	lineNo := 0
+	for s.Scan() {
+		lineNo++
+		cols := strings.Split(s.Text(), "\t")
+		if len(cols) < 5 {
+			return nil, fmt.Errorf("line %d: expected 5 columns", lineNo)
+		}
+		e := FaiEntry{Name: cols[0]}
+		for idx, dst := range []*int64{&e.Length, &e.Offset, &e.LineBases, &e.LineWidth} {
+			n, err := strconv.ParseInt(cols[idx+1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad number %q", lineNo, cols[idx+1])
+			}
+			*dst = n
+		}
+		// only an empty sequence can have no lines
+		if e.Length < 0 || e.Offset < 0 || e.LineBases < 0 || e.LineWidth < e.LineBases ||
+			(e.LineBases == 0 && e.Length > 0) {
+			return nil, fmt.Errorf("line %d: bad line lengths or offset for %s", lineNo, e.Name)
+		}
+		entries = append(entries, e)
+	}
+	return entries, s.Err()
+}
+
+// FastaIndex gives random access to the sequences of an indexed FASTA file.
+type FastaIndex struct {
+	Path    string
+	Entries []FaiEntry
+	byName  map[string]int
+}
+
+var errCompressedFasta = errors.New("compressed FASTA can't be indexed, decompress it first")
+
+// OpenFastaIndex loads path's .fai, creating it first if it is missing or
+// older than the FASTA file. Failing to save a new index is not an error;
+// it just gets rebuilt next time.
+func OpenFastaIndex(path string) (*FastaIndex, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []FaiEntry
+	if fi, err := os.Stat(path + FaiSuffix); err == nil && !fi.ModTime().Before(info.ModTime()) {
+		f, err := os.Open(path + FaiSuffix)
+		if err != nil {
+			return nil, err
+		}
+		entries, err = ReadFai(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path+FaiSuffix, err)
+		}
+	} else {
+		if entries, err = buildFaiFile(path); err != nil {
+			return nil, err
+		}
+		if out, err := os.Create(path + FaiSuffix); err == nil {
+			if WriteFai(out, entries) != nil || out.Close() != nil {
+				os.Remove(path + FaiSuffix)
+			}
+		}
+	}
+
+	idx := &FastaIndex{Path: path, Entries: entries, byName: map[string]int{}}
+	for i, e := range entries {
+		idx.byName[e.Name] = i
+	}
+	return idx, nil
+}
+
+func buildFaiFile(path string) ([]FaiEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var magic [2]byte
+	if n, _ := f.Read(magic[:]); n == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return nil, errCompressedFasta
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	entries, err := BuildFai(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return entries, nil
+}
+
+func (idx *FastaIndex) Entry(name string) (FaiEntry, bool) {
+	i, ok := idx.byName[name]
+	if !ok {
+		return FaiEntry{}, false
+	}
+	return idx.Entries[i], true
+}
+
+// Fetch returns the bases of a region like "chr1:10000-20000" (1-based,
+// inclusive). A bare name or a missing end runs to the end of the sequence,
+// and an end past it is clipped.
+func (idx *FastaIndex) Fetch(r Region) (string, error) {
+	e, ok := idx.Entry(r.Chrom)
+	if !ok {
+		return "", fmt.Errorf("%s is not in %s", r.Chrom, idx.Path)
+	}
+	start, end := max(int64(r.Start), 1), int64(r.End)
+	if end == 0 || end > e.Length {
+		end = e.Length
+	}
+	if start > end {
+		return "", fmt.Errorf("%s is outside %s (%d bp)", r, e.Name, e.Length)
+	}
+
+	// byte offset of a 0-based position
+	pos := func(i int64) int64 {
+		return e.Offset + i/e.LineBases*e.LineWidth + i%e.LineBases
+	}
+	from, to := pos(start-1), pos(end-1)+1
+
+	f, err := os.Open(idx.Path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	buf := make([]byte, to-from)
+	if _, err := f.ReadAt(buf, from); err != nil {
+		return "", fmt.Errorf("%s: %v (is the index out of date?)", idx.Path, err)
+	}
+
+	seq := make([]byte, 0, end-start+1)
+	for _, c := range buf {
+		if c != '\n' && c != '\r' {
+			seq = append(seq, c)
+		}
+	}
+	if int64(len(seq)) != end-start+1 {
+		return "", fmt.Errorf("%s: index doesn't match the file, delete %s and retry", idx.Path, idx.Path+FaiSuffix)
+	}
+	return string(seq), nil
+}
+
+// FetchRecord is Fetch wrapped up as a FASTA record named after the region.
+func (idx *FastaIndex) FetchRecord(r Region) (FastaRecord, error) {
+	seq, err := idx.Fetch(r)
+	if err != nil {
+		return FastaRecord{}, err
+	}
+	name := r.Chrom
+	if r.Start != 0 || r.End != 0 {
+		e, _ := idx.Entry(r.Chrom)
+		end := int64(r.End)
+		if end == 0 || end > e.Length {
+			end = e.Length
+		}
+		name = fmt.Sprintf("%s:%d-%d", r.Chrom, max(r.Start, 1), end)
+	}
+	return FastaRecord{ID: name, Sequence: seq}, nil
+}
+
+// FastaEntries lists the indexed sequences for the local file browser. The
+// header offset isn't in the index, so Offset is -1: read them with Fetch.
+func (idx *FastaIndex) FastaEntries() []FastaEntry {
+	out := make([]FastaEntry, len(idx.Entries))
+	for i, e := range idx.Entries {
+		out[i] = FastaEntry{ID: e.Name, Offset: -1, Length: int(e.Length)}
+	}
+	return out
+}
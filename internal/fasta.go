@@ -0,0 +1,272 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type FastaRecord struct {
+	ID          string
+	Description string
+	Sequence    string
+	Offset      int64 // byte offset of the header line
+}
+
+// Header returns the full header line without the leading '>'.
+func (rec FastaRecord) Header() string {
+	if rec.Description == "" {
+		return rec.ID
+	}
+	return rec.ID + " " + rec.Description
+}
+
+// GBSeq wraps the record in a GBSeq so it can be shown like an NCBI record.
+func (rec FastaRecord) GBSeq() GBSeq {
+	return GBSeq{
+		Locus:            rec.ID,
+		Length:           len(rec.Sequence),
+		MolType:          GuessMolType(rec.Sequence),
+		Definition:       rec.Description,
+		PrimaryAccession: rec.ID,
+		Sequence:         rec.Sequence,
+	}
+}
+
+// GuessMolType looks at the start of a sequence and calls it DNA, RNA or
+// protein.
+func GuessMolType(seq string) string {
+	sample := seq[:min(len(seq), 1000)]
+	nuc, t, u := 0, 0, 0
+	for _, c := range strings.ToUpper(sample) {
+		switch c {
+		case 'A', 'C', 'G', 'N':
+			nuc++
+		case 'T':
+			t++
+		case 'U':
+			u++
+		}
+	}
+	if len(sample) == 0 || float64(nuc+t+u) < 0.9*float64(len(sample)) {
+		return "AA"
+	}
+	if u > t {
+		return "RNA"
+	}
+	return "DNA"
+}
+
+// FastaReader reads multi-record FASTA one record at a time. Sequence lines
+// may be wrapped at any width, and blank lines, ';' comments and Windows line
+// endings are ignored. Case is kept as in the file.
+type FastaReader struct {
+	r      *bufio.Reader
+	offset int64
+	next   string // header of the record after the current one
+	nextAt int64
+	line   int
+	seqLen int
+}
+
+func NewFastaReader(r io.Reader) *FastaReader {
+	return &FastaReader{r: bufio.NewReaderSize(r, 1<<16)}
+}
+
+// SeekFastaReader starts reading at offset, which must point at a header.
+func SeekFastaReader(f io.ReadSeeker, offset int64) (*FastaReader, error) {
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	fr := NewFastaReader(f)
+	fr.offset = offset
+	return fr, nil
+}
+
+func (fr *FastaReader) readLine() ([]byte, int64, error) {
+	at := fr.offset
+	line, err := fr.r.ReadBytes('\n')
+	fr.offset += int64(len(line))
+	if len(line) > 0 {
+		fr.line++
+		err = nil
+	}
+	return bytes.TrimRight(line, "\r\n"), at, err
+}
+
+// Read returns the next record, or io.EOF when there are none left.
+func (fr *FastaReader) Read() (FastaRecord, error) {
+	return fr.read(-1)
+}
+
+// ReadHeader is Read without collecting the sequence, for fast listing.
+// Length is still counted and returned in place of the sequence.
+func (fr *FastaReader) ReadHeader() (FastaRecord, int, error) {
+	rec, err := fr.read(0)
+	return rec, fr.seqLen, err
+}
+
+// ReadPrefix is Read keeping only the first n bases of the sequence. The
+// full length is returned as well.
+func (fr *FastaReader) ReadPrefix(n int) (FastaRecord, int, error) {
+	rec, err := fr.read(n)
+	return rec, fr.seqLen, err
+}
+
+// read keeps up to limit bases of the sequence, or all of them if limit is
+// negative.
+func (fr *FastaReader) read(limit int) (FastaRecord, error) {
+	var rec FastaRecord
+	header, at := fr.next, fr.nextAt
+	fr.next = ""
+
+	// find the first header
+	for header == "" {
+		line, lineAt, err := fr.readLine()
+		if err != nil {
+			return rec, err
+		}
+		if len(line) == 0 || line[0] == ';' {
+			continue
+		}
+		if line[0] != '>' {
+			return rec, fmt.Errorf("line %d: expected a '>' header", fr.line)
+		}
+		header, at = string(line), lineAt
+	}
+
+	rec.ID, rec.Description = cutName(strings.TrimSpace(header[1:]))
+	rec.Description = strings.TrimSpace(rec.Description)
+	rec.Offset = at
+
+	var sb strings.Builder
+	fr.seqLen = 0
+	for {
+		line, lineAt, err := fr.readLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rec, err
+		}
+		if len(line) > 0 && line[0] == '>' {
+			fr.next, fr.nextAt = string(line), lineAt
+			break
+		}
+		if len(line) == 0 || line[0] == ';' {
+			continue
+		}
+		line = bytes.TrimSpace(line)
+		fr.seqLen += len(line)
+		if limit >= 0 {
+			line = line[:max(min(len(line), limit-sb.Len()), 0)]
+		}
+		sb.Write(line)
+	}
+	rec.Sequence = sb.String()
+	return rec, nil
+}
+
+// cutName splits a header at its first space or tab into the sequence name
+// and the rest, the way samtools faidx names sequences.
+func cutName(header string) (string, string) {
+	if i := strings.IndexAny(header, " \t"); i >= 0 {
+		return header[:i], header[i+1:]
+	}
+	return header, ""
+}
+
+// ReadFastaFile reads every record in a FASTA file, which may be gzipped.
+func ReadFastaFile(path string) ([]FastaRecord, error) {
+	f, err := OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var recs []FastaRecord
+	fr := NewFastaReader(f)
+	for {
+		rec, err := fr.Read()
+		if err == io.EOF {
+			return recs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		recs = append(recs, rec)
+	}
+}
+
+// WriteFasta writes records with the sequence wrapped at 60 columns.
+func WriteFasta(w io.Writer, recs []FastaRecord) error {
+	bw := bufio.NewWriter(w)
+	for _, rec := range recs {
+		fmt.Fprintf(bw, ">%s\n", rec.Header())
+		for i := 0; i < len(rec.Sequence); i += 60 {
+			bw.WriteString(rec.Sequence[i:min(i+60, len(rec.Sequence))])
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadFastaRecordAt reads the single record whose header starts at offset,
+// keeping up to limit bases of its sequence (all of them if limit is
+// negative) and returning its full length. Offsets mean nothing inside a
+// compressed file, so gzip is refused.
+func ReadFastaRecordAt(path string, offset int64, limit int) (FastaRecord, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return FastaRecord{}, 0, err
+	}
+	defer f.Close()
+	magic := make([]byte, 2)
+	if n, _ := f.Read(magic); n == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return FastaRecord{}, 0, fmt.Errorf("%s: can't seek into a gzipped FASTA file", path)
+	}
+	fr, err := SeekFastaReader(f, offset)
+	if err != nil {
+		return FastaRecord{}, 0, err
+	}
+	return fr.ReadPrefix(limit)
+}
+
+// FastaEntry is a record header with where to find it again.
+type FastaEntry struct {
+	ID          string
+	Description string
+	Offset      int64
+	Length      int
+}
+
+// ScanFastaFile lists the records in a FASTA file without keeping any
+// sequence in memory, so it is safe on genome-sized files.
+func ScanFastaFile(path string) ([]FastaEntry, error) {
+	f, err := OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []FastaEntry
+	fr := NewFastaReader(f)
+	for {
+		rec, length, err := fr.ReadHeader()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		entries = append(entries, FastaEntry{
+			ID:          rec.ID,
+			Description: rec.Description,
+			Offset:      rec.Offset,
+			Length:      length,
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type fastqPage struct {
+	Title    string
+	Path     string
+	Stats    *FastqStats
+	Viewport viewport.Model
+	Spinner  spinner.Model
+	Loading  bool
+	Width    int
+	Err      error
+}
+
+func NewFastqPage(path string, width, height int) *fastqPage {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+
+	return &fastqPage{
+		Title:    filepath.Base(path),
+		Path:     path,
+		Viewport: viewport.New(width, height-2),
+		Spinner:  s,
+		Width:    width,
+	}
+}
+
+type fastqStatsMsg struct {
+	stats *FastqStats
+}
+
+// Start reads the whole file in the background.
+func (page *fastqPage) Start() tea.Cmd {
+	page.Loading = true
+	path := page.Path
+	return tea.Batch(page.Spinner.Tick, func() tea.Msg {
+		st, err := SummarizeFastq(path)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return fastqStatsMsg{stats: st}
+	})
+}
+
+// UpdatePage implements page.
+func (page *fastqPage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		m.UpdateBack(msg)
+	case fastqStatsMsg:
+		page.Loading = false
+		page.Stats = msg.stats
+		page.Viewport.SetContent(FastqReport(msg.stats, page.Width))
+	case errMsg:
+		page.Loading = false
+		page.Err = msg.err
+	}
+
+	var cmd tea.Cmd
+	if page.Loading {
+		page.Spinner, cmd = page.Spinner.Update(msg)
+	} else {
+		page.Viewport, cmd = page.Viewport.Update(msg)
+	}
+	return m, cmd
+}
+
+// FastqReport renders the summary with charts fitted to width.
+func FastqReport(st *FastqStats, width int) string {
+	var sb strings.Builder
+	padding := fmt.Sprintf("%%-%ds", LabelPadding)
+
+	sb.WriteString("=== READ SUMMARY ===\n")
+	sb.WriteString(fmt.Sprintf(padding+" %d\n", "Reads:", st.Reads))
+	sb.WriteString(fmt.Sprintf(padding+" %d\n", "Bases:", st.Bases))
+	sb.WriteString(fmt.Sprintf(padding+" Phred+%d\n", "Encoding:", st.PhredOffset()))
+	sb.WriteString(fmt.Sprintf(padding+" %.1f%%\n", "GC content:", st.GCPercent()))
+	sb.WriteString(fmt.Sprintf(padding+" %.3f%%\n", "N rate:", st.NPercent()))
+	if st.Reads == 0 {
+		return sb.String()
+	}
+
+	sb.WriteString("\n--- READ LENGTHS ---\n")
+	labels, counts := lengthHistogram(st.Lengths, 10)
+	sb.WriteString(BarChart(labels, counts, max(width-30, 10)))
+
+	sb.WriteString("\n--- MEAN QUALITY PER POSITION ---\n")
+	qual := st.MeanQuality()
+	hi := 40.0
+	for _, q := range qual {
+		hi = max(hi, q)
+	}
+	cols := Downsample(qual, max(width-10, 10))
+	sb.WriteString(ColumnChart(cols, 0, hi, 8))
+	sb.WriteString(fmt.Sprintf("%8s1%*d\n", "", max(len(cols)-1, 1), len(qual)))
+
+	sb.WriteString("\n--- PER-READ GC CONTENT ---\n")
+	// leave out the empty bins at either end
+	lo, top := 0, gcBins-1
+	for lo < top && st.ReadGC[lo] == 0 {
+		lo++
+	}
+	for top > lo && st.ReadGC[top] == 0 {
+		top--
+	}
+	labels, counts = nil, nil
+	for idx := lo; idx <= top; idx++ {
+		labels = append(labels, fmt.Sprintf("%d-%d%%", idx*100/gcBins, (idx+1)*100/gcBins))
+		counts = append(counts, float64(st.ReadGC[idx]))
+	}
+	sb.WriteString(BarChart(labels, counts, max(width-30, 10)))
+
+	sb.WriteString("\n--- N RATE PER POSITION ---\n")
+	nRate := st.NRate()
+	hi = 1.0
+	for _, r := range nRate {
+		hi = max(hi, r)
+	}
+	cols = Downsample(nRate, max(width-10, 10))
+	sb.WriteString(ColumnChart(cols, 0, hi, 4))
+	sb.WriteString(fmt.Sprintf("%8s1%*d\n", "", max(len(cols)-1, 1), len(nRate)))
+
+	sb.WriteString("\n--- OVER-REPRESENTED SEQUENCES ---\n")
+	over := st.Overrepresented()
+	if len(over) == 0 {
+		sb.WriteString("none\n")
+	}
+	for _, o := range over[:min(len(over), 20)] {
+		sb.WriteString(fmt.Sprintf("%-50s %8d %6.2f%%\n", o.Sequence, o.Count, o.Percent))
+	}
+	return sb.String()
+}
+
+// lengthHistogram groups read lengths into at most bins ranges.
+func lengthHistogram(lengths map[int]int, bins int) ([]string, []float64) {
+	keys := make([]int, 0, len(lengths))
+	for l := range lengths {
+		keys = append(keys, l)
+	}
+	sort.Ints(keys)
+	if len(keys) <= bins {
+		labels := make([]string, len(keys))
+		counts := make([]float64, len(keys))
+		for idx, l := range keys {
+			labels[idx] = fmt.Sprint(l)
+			counts[idx] = float64(lengths[l])
+		}
+		return labels, counts
+	}
+
+	lo, hi := keys[0], keys[len(keys)-1]
+	step := (hi - lo + bins) / bins
+	labels := make([]string, bins)
+	counts := make([]float64, bins)
+	for idx := range labels {
+		from := lo + idx*step
+		labels[idx] = fmt.Sprintf("%d-%d", from, from+step-1)
+	}
+	for l, n := range lengths {
+		counts[min((l-lo)/step, bins-1)] += float64(n)
+	}
+	return labels, counts
+}
+
+// Page implements page.
+func (page *fastqPage) Page(m Model) string {
+	if page.Err != nil {
+		return errorStyle.Render(page.Err.Error()) + "\n\n"
+	}
+	if page.Loading {
+		return page.Spinner.View() + " Reading " + page.Path + " ... \n\n"
+	}
+	return fmt.Sprintf("%s\n%s\n%s", headerView(page.Title, page.Width), page.Viewport.View(), footerView(page.Width))
+}
+
+func (page *fastqPage) GetTitle() string {
+	return page.Title
+}
@@ -0,0 +1,240 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type FastqRecord struct {
+	ID          string
+	Description string
+	Sequence    string
+	Quality     string
+}
+
+// FastqReader reads four-line FASTQ records. Plain or gzipped input works the
+// same when opened through OpenFile.
+type FastqReader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func NewFastqReader(r io.Reader) *FastqReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 1<<16), 1<<24)
+	return &FastqReader{s: s}
+}
+
+func (fr *FastqReader) scan() (string, bool) {
+	if !fr.s.Scan() {
+		return "", false
+	}
+	fr.line++
+	return strings.TrimRight(fr.s.Text(), "\r"), true
+}
+
+// Read returns the next record, or io.EOF when there are none left.
+func (fr *FastqReader) Read() (FastqRecord, error) {
+	var rec FastqRecord
+
+	header, ok := fr.scan()
+	for ok && header == "" {
+		header, ok = fr.scan()
+	}
+	if !ok {
+		if err := fr.s.Err(); err != nil {
+			return rec, err
+		}
+		return rec, io.EOF
+	}
+	if header[0] != '@' {
+		return rec, fmt.Errorf("line %d: expected an '@' header", fr.line)
+	}
+	rec.ID, rec.Description, _ = strings.Cut(header[1:], " ")
+
+	var lines [3]string
+	for idx := range lines {
+		if lines[idx], ok = fr.scan(); !ok {
+			return rec, fmt.Errorf("line %d: truncated record %s", fr.line, rec.ID)
+		}
+	}
+	if !strings.HasPrefix(lines[1], "+") {
+		return rec, fmt.Errorf("line %d: expected a '+' separator", fr.line-1)
+	}
+	rec.Sequence, rec.Quality = lines[0], lines[2]
+	if len(rec.Sequence) != len(rec.Quality) {
+		return rec, fmt.Errorf("line %d: sequence and quality lengths differ for %s", fr.line, rec.ID)
+	}
+	return rec, nil
+}
+
+// only the first reads are checked for over-represented sequences, as FastQC
+// does, so memory stays bounded on big files
+const (
+	overrepSample = 100000
+	overrepPrefix = 50
+)
+
+// FastqStats is a streaming summary of a FASTQ file.
+type FastqStats struct {
+	Reads     int
+	Bases     int64
+	Lengths   map[int]int
+	GC        int64
+	N         int64
+	MinQual   byte
+	ReadGC    [gcBins]int // reads by GC content of their called bases
+	qualSum   []int64
+	qualCount []int64
+	nCount    []int64 // Ns at each position
+	seqCount  []int64 // bases at each position
+	prefixes  map[string]int
+}
+
+// reads are binned by GC content in steps of 100/gcBins percent
+const gcBins = 20
+
+func NewFastqStats() *FastqStats {
+	return &FastqStats{
+		Lengths:  map[int]int{},
+		MinQual:  0xff,
+		prefixes: map[string]int{},
+	}
+}
+
+func (st *FastqStats) Add(rec FastqRecord) {
+	st.Reads++
+	st.Bases += int64(len(rec.Sequence))
+	st.Lengths[len(rec.Sequence)]++
+
+	for len(st.qualSum) < len(rec.Quality) {
+		st.qualSum = append(st.qualSum, 0)
+		st.qualCount = append(st.qualCount, 0)
+	}
+	for idx := 0; idx < len(rec.Quality); idx++ {
+		q := rec.Quality[idx]
+		st.qualSum[idx] += int64(q)
+		st.qualCount[idx]++
+		st.MinQual = min(st.MinQual, q)
+	}
+	for len(st.seqCount) < len(rec.Sequence) {
+		st.nCount = append(st.nCount, 0)
+		st.seqCount = append(st.seqCount, 0)
+	}
+	var gc, n int
+	for idx := 0; idx < len(rec.Sequence); idx++ {
+		st.seqCount[idx]++
+		switch rec.Sequence[idx] {
+		case 'G', 'C', 'g', 'c':
+			gc++
+		case 'N', 'n', '.':
+			n++
+			st.nCount[idx]++
+		}
+	}
+	st.GC += int64(gc)
+	st.N += int64(n)
+	if called := len(rec.Sequence) - n; called > 0 {
+		st.ReadGC[min(gc*gcBins/called, gcBins-1)]++
+	}
+
+	if st.Reads <= overrepSample {
+		prefix := strings.ToUpper(rec.Sequence[:min(len(rec.Sequence), overrepPrefix)])
+		st.prefixes[prefix]++
+	}
+}
+
+// PhredOffset guesses the quality encoding from the lowest character seen,
+// with the same cutoff as FastQC: anything below '@' can only be Phred+33.
+func (st *FastqStats) PhredOffset() int {
+	if st.MinQual < 64 || st.Reads == 0 {
+		return 33
+	}
+	return 64
+}
+
+// MeanQuality returns the mean Phred score at each read position.
+func (st *FastqStats) MeanQuality() []float64 {
+	offset := float64(st.PhredOffset())
+	out := make([]float64, len(st.qualSum))
+	for idx := range out {
+		out[idx] = float64(st.qualSum[idx])/float64(st.qualCount[idx]) - offset
+	}
+	return out
+}
+
+// GCPercent is the GC content of the called (non-N) bases.
+func (st *FastqStats) GCPercent() float64 {
+	called := st.Bases - st.N
+	if called <= 0 {
+		return 0
+	}
+	return 100 * float64(st.GC) / float64(called)
+}
+
+func (st *FastqStats) NPercent() float64 {
+	if st.Bases == 0 {
+		return 0
+	}
+	return 100 * float64(st.N) / float64(st.Bases)
+}
+
+// NRate returns the percentage of Ns at each read position.
+func (st *FastqStats) NRate() []float64 {
+	out := make([]float64, len(st.seqCount))
+	for idx := range out {
+		out[idx] = 100 * float64(st.nCount[idx]) / float64(st.seqCount[idx])
+	}
+	return out
+}
+
+type Overrepresented struct {
+	Sequence string
+	Count    int
+	Percent  float64
+}
+
+// Overrepresented lists sequences making up more than 0.1% of the sampled
+// reads, most common first.
+func (st *FastqStats) Overrepresented() []Overrepresented {
+	sampled := min(st.Reads, overrepSample)
+	var out []Overrepresented
+	for s, n := range st.prefixes {
+		pct := 100 * float64(n) / float64(sampled)
+		if pct > 0.1 && n > 1 {
+			out = append(out, Overrepresented{Sequence: s, Count: n, Percent: pct})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Sequence < out[j].Sequence
+	})
+	return out
+}
+
+// SummarizeFastq reads a whole FASTQ file, plain or gzipped.
+func SummarizeFastq(path string) (*FastqStats, error) {
+	f, err := OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	st := NewFastqStats()
+	fr := NewFastqReader(f)
+	for {
+		rec, err := fr.Read()
+		if err == io.EOF {
+			return st, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		st.Add(rec)
+	}
+}
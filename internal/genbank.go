@@ -0,0 +1,557 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// GenBankReader parses GenBank flat files (.gb, .gbk) into GBSeq, filling the
+// same fields the EFetch XML does. Files may hold any number of records, each
+// terminated by "//".
+type GenBankReader struct {
+	s    *bufio.Scanner
+	line int
+}
+
+func NewGenBankReader(r io.Reader) *GenBankReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 1<<16), 1<<24)
+	return &GenBankReader{s: s}
+}
+
+func (gr *GenBankReader) scan() (string, bool) {
+	if !gr.s.Scan() {
+		return "", false
+	}
+	gr.line++
+	return strings.TrimRight(gr.s.Text(), "\r"), true
+}
+
+// genbankField is one keyword and its lines, continuation lines included.
+type genbankField struct {
+	key   string
+	lines []string
+}
+
+func (f genbankField) text() string {
+	return strings.Join(f.lines, " ")
+}
+
+// Read returns the next record, or io.EOF when there are none left.
+func (gr *GenBankReader) Read() (GBSeq, error) {
+	var seq GBSeq
+
+	// find LOCUS
+	for {
+		line, ok := gr.scan()
+		if !ok {
+			if err := gr.s.Err(); err != nil {
+				return seq, err
+			}
+			return seq, io.EOF
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "LOCUS") {
+			return seq, fmt.Errorf("line %d: expected LOCUS", gr.line)
+		}
+		parseLocus(&seq, line)
+		break
+	}
+
+	var field *genbankField
+	flush := func() {
+		if field != nil {
+			applyGenBankField(&seq, *field)
+			field = nil
+		}
+	}
+
+	var sb strings.Builder
+	inOrigin := false
+	for {
+		line, ok := gr.scan()
+		if !ok {
+			if err := gr.s.Err(); err != nil {
+				return seq, err
+			}
+			return seq, fmt.Errorf("line %d: record %s is missing its closing //", gr.line, seq.Locus)
+		}
+		if strings.HasPrefix(line, "//") {
+			break
+		}
+		if inOrigin {
+			sb.WriteString(stripSequenceLine(line))
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		key, rest := splitGenBankLine(line)
+		switch {
+		case key == "FEATURES":
+			flush()
+			features, next, err := gr.readFeatures()
+			if err != nil {
+				return seq, err
+			}
+			seq.Features = features
+			// readFeatures stops on the first line that isn't a feature
+			if strings.HasPrefix(next, "//") {
+				seq.Sequence = sb.String()
+				return finishGenBank(seq), nil
+			}
+			if strings.HasPrefix(next, "ORIGIN") {
+				inOrigin = true
+				continue
+			}
+			key, rest = splitGenBankLine(next)
+			if key == "" {
+				continue
+			}
+			field = &genbankField{key: key, lines: []string{rest}}
+		case key == "ORIGIN":
+			flush()
+			inOrigin = true
+		case key != "":
+			flush()
+			field = &genbankField{key: key, lines: []string{rest}}
+		case field != nil:
+			field.lines = append(field.lines, rest)
+		}
+	}
+	flush()
+	seq.Sequence = sb.String()
+	return finishGenBank(seq), nil
+}
+
+func finishGenBank(seq GBSeq) GBSeq {
+	if seq.Length == 0 {
+		seq.Length = len(seq.Sequence)
+	}
+	return seq
+}
+
+// splitGenBankLine separates the 12 column keyword area from the value. Sub
+// keywords such as "  ORGANISM" keep their own name; continuation lines come
+// back with an empty key.
+func splitGenBankLine(line string) (string, string) {
+	if len(line) <= 12 {
+		return strings.TrimSpace(line), ""
+	}
+	return strings.TrimSpace(line[:12]), strings.TrimSpace(line[12:])
+}
+
+func stripSequenceLine(line string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\t' || (r >= '0' && r <= '9') {
+			return -1
+		}
+		return r
+	}, line)
+}
+
+func parseLocus(seq *GBSeq, line string) {
+	tokens := strings.Fields(line)
+	if len(tokens) > 1 {
+		seq.Locus = tokens[1]
+	}
+	for idx := 2; idx < len(tokens); idx++ {
+		tok := tokens[idx]
+		switch {
+		case tok == "bp":
+		case tok == "aa":
+			seq.MolType = "AA"
+		case idx == 2:
+			seq.Length, _ = strconv.Atoi(tok)
+		case tok == "linear" || tok == "circular":
+			seq.Topology = tok
+		case isGenBankDate(tok):
+			seq.UpdateDate = tok
+		case len(tok) == 3 && strings.ToUpper(tok) == tok && seq.MolType != "":
+			seq.Division = tok
+		default:
+			if strand, mol, ok := strings.Cut(tok, "-"); ok && len(strand) == 2 {
+				seq.StrandType = map[string]string{"ss": "single", "ds": "double", "ms": "mixed"}[strand]
+				tok = mol
+			}
+			seq.MolType = tok
+		}
+	}
+}
+
+func isGenBankDate(s string) bool {
+	return len(s) == 11 && s[2] == '-' && s[6] == '-'
+}
+
+func applyGenBankField(seq *GBSeq, f genbankField) {
+	text := f.text()
+	switch f.key {
+	case "DEFINITION":
+		seq.Definition = strings.TrimSuffix(text, ".")
+	case "ACCESSION":
+		if fields := strings.Fields(text); len(fields) > 0 {
+			seq.PrimaryAccession = fields[0]
+		}
+	case "VERSION":
+		if fields := strings.Fields(text); len(fields) > 0 {
+			seq.AccessionVersion = fields[0]
+		}
+	case "KEYWORDS":
+		seq.Keywords = splitKeywords(text)
+	case "SOURCE":
+		seq.Source = text
+	case "ORGANISM":
+		seq.Organism = f.lines[0]
+		seq.Taxonomy = strings.TrimSuffix(strings.Join(f.lines[1:], " "), ".")
+	case "COMMENT":
+		seq.Comment = text
+	case "REFERENCE":
+		seq.References = append(seq.References, parseReferenceLine(text))
+	case "AUTHORS", "TITLE", "JOURNAL", "PUBMED", "CONSRTM":
+		if len(seq.References) == 0 {
+			return
+		}
+		ref := &seq.References[len(seq.References)-1]
+		switch f.key {
+		case "AUTHORS":
+			ref.Authors = splitAuthors(text)
+		case "CONSRTM":
+			ref.Authors = append(ref.Authors, text)
+		case "TITLE":
+			ref.Title = text
+		case "JOURNAL":
+			ref.Journal = text
+		case "PUBMED":
+			ref.PubMed = text
+		}
+	}
+}
+
+func splitKeywords(text string) []string {
+	text = strings.TrimSuffix(strings.TrimSpace(text), ".")
+	if text == "" {
+		return nil
+	}
+	var out []string
+	for _, kw := range strings.Split(text, ";") {
+		if kw = strings.TrimSpace(kw); kw != "" {
+			out = append(out, kw)
+		}
+	}
+	return out
+}
+
+func splitAuthors(text string) []string {
+	text = strings.ReplaceAll(text, " and ", ", ")
+	var out []string
+	for _, a := range strings.Split(text, ".,") {
+		if a = strings.TrimSpace(a); a != "" {
+			if !strings.HasSuffix(a, ".") {
+				a += "."
+			}
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// parseReferenceLine reads "1  (bases 1 to 2512; 3000 to 3200)".
+func parseReferenceLine(text string) GBRef {
+	var ref GBRef
+	num, rest, _ := strings.Cut(text, " ")
+	ref.RefNumber, _ = strconv.Atoi(num)
+	rest = strings.TrimSpace(rest)
+	rest = strings.TrimPrefix(rest, "(")
+	rest = strings.TrimSuffix(rest, ")")
+	rest = strings.TrimPrefix(rest, "bases ")
+	rest = strings.TrimPrefix(rest, "residues ")
+	ref.Position = strings.ReplaceAll(rest, " to ", "..")
+	return ref
+}
+
+// readFeatures reads the feature table. It returns the first line after it.
+func (gr *GenBankReader) readFeatures() ([]GBFeature, string, error) {
+	var features []GBFeature
+	var fb *featureBuilder
+
+	for {
+		line, ok := gr.scan()
+		if !ok {
+			if err := gr.s.Err(); err != nil {
+				return nil, "", err
+			}
+			return nil, "", fmt.Errorf("line %d: unexpected end of file in FEATURES", gr.line)
+		}
+		if len(line) > 0 && line[0] != ' ' {
+			if fb != nil {
+				features = append(features, fb.feature())
+			}
+			return features, line, nil
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if len(line) > 5 && line[5] != ' ' {
+			if fb != nil {
+				features = append(features, fb.feature())
+			}
+			key, loc := strings.TrimSpace(line[:min(21, len(line))]), ""
+			if len(line) > 21 {
+				loc = strings.TrimSpace(line[21:])
+			}
+			fb = &featureBuilder{key: key, location: loc}
+			continue
+		}
+		if fb != nil {
+			fb.add(strings.TrimSpace(line))
+		}
+	}
+}
+
+// featureBuilder collects the lines of one feature, as found in both GenBank
+// and EMBL feature tables.
+type featureBuilder struct {
+	key      string
+	location string
+	quals    [][]string
+}
+
+func (fb *featureBuilder) add(text string) {
+	if strings.HasPrefix(text, "/") {
+		fb.quals = append(fb.quals, []string{text[1:]})
+		return
+	}
+	if len(fb.quals) == 0 {
+		fb.location += text
+		return
+	}
+	last := len(fb.quals) - 1
+	fb.quals[last] = append(fb.quals[last], text)
+}
+
+func (fb *featureBuilder) feature() GBFeature {
+	f := GBFeature{Key: fb.key, Location: fb.location}
+	f.Intervals, _ = ParseLocation(fb.location)
+	for _, lines := range fb.quals {
+		name, value, _ := strings.Cut(lines[0], "=")
+		sep := " "
+		if name == "translation" {
+			sep = ""
+		}
+		value = strings.Join(append([]string{value}, lines[1:]...), sep)
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = strings.ReplaceAll(value[1:len(value)-1], `""`, `"`)
+		}
+		f.Quals = append(f.Quals, GBQualifier{Name: name, Value: value})
+	}
+	return f
+}
+
+// ReadGenBankFile reads every record in a GenBank flat file, which may be gzipped.
+func ReadGenBankFile(path string) ([]GBSeq, error) {
+	f, err := OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var seqs []GBSeq
+	gr := NewGenBankReader(f)
+	for {
+		seq, err := gr.Read()
+		if err == io.EOF {
+			return seqs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		seqs = append(seqs, seq)
+	}
+}
+
+// qualifiers whose values are written without quotes
+var unquotedQualifiers = map[string]bool{
+	"anticodon": true, "citation": true, "codon_start": true, "compare": true,
+	"direction": true, "estimated_length": true, "mod_base": true, "number": true,
+	"rpt_type": true, "rpt_unit_range": true, "tag_peptide": true,
+	"transl_except": true, "transl_table": true,
+}
+
+// wrapText breaks text into lines of at most width characters, on spaces
+// where it can (or after sep, e.g. ',' for locations). Readers join text
+// lines back with a space, so a word too long for a line is kept whole and
+// overruns it; with an empty sep, as for translations, lines are cut at
+// exactly width instead.
+func wrapText(text string, width int, sep string) []string {
+	var lines []string
+	for len(text) > width {
+		cut := strings.LastIndex(text[:width+1], " ")
+		switch {
+		case sep == "":
+			cut = width
+		case sep != " ":
+			if idx := strings.LastIndex(text[:width], sep); idx >= 0 {
+				cut = idx + len(sep)
+			}
+			if cut <= 0 {
+				cut = width
+			}
+		case cut <= 0:
+			idx := strings.IndexByte(text[1:], ' ')
+			if idx < 0 {
+				return append(lines, text)
+			}
+			cut = idx + 1
+		}
+		lines = append(lines, strings.TrimRight(text[:cut], " "))
+		text = strings.TrimLeft(text[cut:], " ")
+	}
+	return append(lines, text)
+}
+
+// writeFeatureTable writes features in the INSDC layout shared by GenBank and
+// EMBL: key at column 6, location and qualifiers at column 22. prefix is what
+// goes in the first five columns.
+func writeFeatureTable(w io.Writer, features []GBFeature, prefix string) error {
+	indent := prefix + strings.Repeat(" ", 16)
+	for _, f := range features {
+		for idx, line := range wrapText(f.Location, 58, ",") {
+			head := indent
+			if idx == 0 {
+				head = fmt.Sprintf("%s%-16s", prefix, f.Key)
+			}
+			if _, err := fmt.Fprintf(w, "%s%s\n", head, line); err != nil {
+				return err
+			}
+		}
+		for _, q := range f.Quals {
+			text := "/" + q.Name
+			switch {
+			case q.Value == "":
+				// flags such as /pseudo
+			case unquotedQualifiers[q.Name]:
+				text += "=" + q.Value
+			default:
+				text += `="` + strings.ReplaceAll(q.Value, `"`, `""`) + `"`
+			}
+			sep := " "
+			if q.Name == "translation" {
+				sep = ""
+			}
+			for _, line := range wrapText(text, 58, sep) {
+				if _, err := fmt.Fprintf(w, "%s%s\n", indent, line); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// writeGenBankField writes a keyword and its value wrapped to 80 columns.
+func writeGenBankField(w io.Writer, key, value string) error {
+	for idx, line := range wrapText(value, 67, " ") {
+		if idx == 0 {
+			if _, err := fmt.Fprintf(w, "%-12s%s\n", key, line); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%12s%s\n", "", line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteGenBank writes a record as a GenBank flat file entry.
+func WriteGenBank(w io.Writer, seq GBSeq) error {
+	bw := bufio.NewWriter(w)
+
+	unit, mol, strand := "bp", seq.MolType, ""
+	if IsProtein(seq) {
+		unit, mol = "aa", ""
+	}
+	switch seq.StrandType {
+	case "single":
+		strand = "ss-"
+	case "double":
+		strand = "ds-"
+	case "mixed":
+		strand = "ms-"
+	}
+	fmt.Fprintf(bw, "LOCUS       %-16s %11d %s %3s%-6s  %-8s %s %s\n",
+		seq.Locus, seq.Length, unit, strand, mol, seq.Topology, seq.Division, seq.UpdateDate)
+
+	writeGenBankField(bw, "DEFINITION", seq.Definition+".")
+	writeGenBankField(bw, "ACCESSION", seq.PrimaryAccession)
+	if seq.AccessionVersion != "" {
+		writeGenBankField(bw, "VERSION", seq.AccessionVersion)
+	}
+	keywords := "."
+	if len(seq.Keywords) > 0 {
+		keywords = strings.Join(seq.Keywords, "; ") + "."
+	}
+	writeGenBankField(bw, "KEYWORDS", keywords)
+	source := seq.Source
+	if source == "" {
+		source = seq.Organism
+	}
+	writeGenBankField(bw, "SOURCE", source)
+	writeGenBankField(bw, "  ORGANISM", seq.Organism)
+	if seq.Taxonomy != "" {
+		for _, line := range wrapText(seq.Taxonomy+".", 67, " ") {
+			fmt.Fprintf(bw, "%12s%s\n", "", line)
+		}
+	}
+
+	for _, ref := range seq.References {
+		pos := ""
+		if ref.Position != "" {
+			pos = fmt.Sprintf("  (%s %s)", map[bool]string{true: "residues", false: "bases"}[unit == "aa"],
+				strings.ReplaceAll(ref.Position, "..", " to "))
+		}
+		writeGenBankField(bw, "REFERENCE", fmt.Sprintf("%d%s", ref.RefNumber, pos))
+		if len(ref.Authors) > 0 {
+			writeGenBankField(bw, "  AUTHORS", joinAuthors(ref.Authors))
+		}
+		if ref.Title != "" {
+			writeGenBankField(bw, "  TITLE", ref.Title)
+		}
+		if ref.Journal != "" {
+			writeGenBankField(bw, "  JOURNAL", ref.Journal)
+		}
+		if ref.PubMed != "" {
+			writeGenBankField(bw, "   PUBMED", ref.PubMed)
+		}
+	}
+	if seq.Comment != "" {
+		writeGenBankField(bw, "COMMENT", seq.Comment)
+	}
+
+	fmt.Fprintln(bw, "FEATURES             Location/Qualifiers")
+	writeFeatureTable(bw, seq.Features, "     ")
+
+	fmt.Fprintln(bw, "ORIGIN      ")
+	bw.WriteString(FormatSequence(strings.ToLower(seq.Sequence)))
+	fmt.Fprintln(bw, "//")
+	return bw.Flush()
+}
+
+func joinAuthors(authors []string) string {
+	if len(authors) == 1 {
+		return authors[0]
+	}
+	return strings.Join(authors[:len(authors)-1], ", ") + " and " + authors[len(authors)-1]
+}
+
+// IsProtein reports whether a record holds an amino acid sequence.
+func IsProtein(seq GBSeq) bool {
+	return strings.EqualFold(seq.MolType, "AA") || strings.EqualFold(seq.MolType, "protein")
+}
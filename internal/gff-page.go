@@ -0,0 +1,245 @@
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type gffItem struct {
+	f *GFFFeature
+}
+
+func (i gffItem) Title() string {
+	return fmt.Sprintf("%s  %s", i.f.Type, i.f.Name())
+}
+
+func (i gffItem) Description() string {
+	d := fmt.Sprintf("%s:%d-%d (%s)", i.f.SeqID, i.f.Start, i.f.End, i.f.Strand)
+	if n := len(i.f.Children); n > 0 {
+		d += fmt.Sprintf(" - %d children", n)
+	}
+	return d
+}
+
+// FilterValue is the title followed by every attribute value as key=value,
+// tab separated so gffFilter can tell them apart.
+func (i gffItem) FilterValue() string {
+	parts := []string{i.Title()}
+	for _, a := range i.f.Attributes {
+		for _, v := range a.Values {
+			parts = append(parts, a.Key+"="+v)
+		}
+	}
+	return strings.Join(parts, "\t")
+}
+
+// gffFilter matches "key=value" exactly against the attributes, so
+// gene_biotype=lncRNA doesn't also find lncRNA_antisense, and anything else
+// fuzzily against the title.
+func gffFilter(term string, targets []string) []list.Rank {
+	key, value, ok := strings.Cut(term, "=")
+	if !ok {
+		titles := make([]string, len(targets))
+		for i, t := range targets {
+			titles[i], _, _ = strings.Cut(t, "\t")
+		}
+		return list.DefaultFilter(term, titles)
+	}
+	attr := strings.TrimSpace(key) + "=" + strings.TrimSpace(value)
+	var ranks []list.Rank
+	for i, t := range targets {
+		if slices.Contains(strings.Split(t, "\t")[1:], attr) {
+			ranks = append(ranks, list.Rank{Index: i})
+		}
+	}
+	return ranks
+}
+
+type gffPage struct {
+	Title      string
+	Source     string
+	File       *GFFFile
+	Results    list.Model
+	Path       []*GFFFeature // features drilled into, outermost first
+	SeqIDs     []string
+	Types      []string
+	SeqCounts  map[string]int // features on each sequence
+	TypeCounts map[string]int // features of each type
+	SeqID      int            // index into SeqIDs, -1 for all
+	Type       int            // index into Types, -1 for top level features only
+	Convert    convertPrompt
+	Status     string
+}
+
+func NewGFFPage(path string, g *GFFFile, width, height int) *gffPage {
+	d := list.NewDefaultDelegate()
+	d.UpdateFunc = UpdateDelegate
+
+	page := &gffPage{
+		Title:   filepath.Base(path),
+		Source:  path,
+		File:    g,
+		Results: list.New(nil, d, width, height-4),
+		SeqID:   -1,
+		Type:    -1,
+		Convert: newConvertPrompt(),
+	}
+	page.Results.Filter = gffFilter
+	page.SeqIDs, page.SeqCounts = g.SeqIDs()
+	page.Types, page.TypeCounts = g.Types()
+	page.refresh()
+	return page
+}
+
+// refresh rebuilds the list for the current level and filters.
+func (page *gffPage) refresh() {
+	var features []*GFFFeature
+	switch {
+	case len(page.Path) > 0:
+		features = page.Path[len(page.Path)-1].Children
+	case page.Type >= 0:
+		for _, f := range page.File.Features {
+			if f.Type == page.Types[page.Type] {
+				features = append(features, f)
+			}
+		}
+	default:
+		features = page.File.Roots()
+	}
+
+	var items []list.Item
+	for _, f := range features {
+		if page.SeqID >= 0 && f.SeqID != page.SeqIDs[page.SeqID] {
+			continue
+		}
+		items = append(items, gffItem{f: f})
+	}
+	page.Results.SetItems(items)
+	page.Results.ResetSelected()
+	page.Results.Title = page.listTitle()
+}
+
+func (page *gffPage) listTitle() string {
+	if len(page.Path) > 0 {
+		names := make([]string, len(page.Path))
+		for idx, f := range page.Path {
+			names[idx] = f.Name()
+		}
+		return strings.Join(names, " > ")
+	}
+	title := "top level features"
+	if page.Type >= 0 {
+		title = "all " + page.Types[page.Type]
+	}
+	if page.SeqID >= 0 {
+		title += " on " + page.SeqIDs[page.SeqID]
+	}
+	return title
+}
+
+// UpdatePage implements page.
+func (page *gffPage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
+	filtering := page.Results.FilterState() == list.Filtering
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if page.Convert.Active {
+			return m, page.Convert.Update(msg, page.Source, m)
+		}
+		if filtering {
+			break
+		}
+		switch {
+		case msg.String() == "c":
+			return m, page.Convert.Start()
+		case msg.String() == "t" && len(page.Path) == 0:
+			page.Type++
+			if page.Type >= len(page.Types) {
+				page.Type = -1
+			}
+			page.refresh()
+			return m, nil
+		case msg.String() == "s" && len(page.Path) == 0:
+			page.SeqID++
+			if page.SeqID >= len(page.SeqIDs) {
+				page.SeqID = -1
+			}
+			page.refresh()
+			return m, nil
+		case key.Matches(msg, m.Keys.Back) && page.Results.FilterState() == list.Unfiltered:
+			if len(page.Path) == 0 {
+				m.UpdateBack(msg)
+				return m, nil
+			}
+			page.Path = page.Path[:len(page.Path)-1]
+			page.refresh()
+			return m, nil
+		}
+
+	case convertedMsg:
+		page.Status = msg.String()
+		return m, nil
+
+	case listSelectMsg:
+		item, ok := page.Results.SelectedItem().(gffItem)
+		if ok && len(item.f.Children) > 0 {
+			page.Path = append(page.Path, item.f)
+			page.Results.ResetFilter()
+			page.refresh()
+		}
+		return m, nil
+
+	case tea.WindowSizeMsg:
+		page.Results.SetSize(msg.Width-20, msg.Height-12)
+	}
+
+	var cmd tea.Cmd
+	page.Results, cmd = page.Results.Update(msg)
+	return m, cmd
+}
+
+func countsLine(label string, keys []string, counts map[string]int, current int) string {
+	parts := make([]string, len(keys))
+	for idx, k := range keys {
+		parts[idx] = fmt.Sprintf("%s (%d)", k, counts[k])
+		if idx == current {
+			parts[idx] = selectedRowStyle.Render(parts[idx])
+		}
+	}
+	return label + strings.Join(parts, ", ")
+}
+
+// Page implements page.
+func (page *gffPage) Page(m Model) string {
+	p := fmt.Sprintf("%s annotation, %d features\n", strings.ToUpper(page.File.Format), len(page.File.Features))
+	p += countsLine("Sequences: ", page.SeqIDs, page.SeqCounts, page.SeqID) + "\n"
+	p += countsLine("Types:     ", page.Types, page.TypeCounts, page.Type) + "\n"
+	if page.Convert.Active {
+		p += page.Convert.Input.View() + "\n\n"
+	} else {
+		p += faintStyle.Render("enter: open children • bksp: up a level • s: cycle sequence • t: cycle type • /: filter by name or attribute • c: convert") + "\n\n"
+	}
+	p += page.Results.View()
+
+	if item, ok := page.Results.SelectedItem().(gffItem); ok {
+		var attrs []string
+		for _, a := range item.f.Attributes {
+			attrs = append(attrs, a.Key+"="+strings.Join(a.Values, ","))
+		}
+		p += "\n" + faintStyle.Render(strings.Join(attrs, "; "))
+	}
+	if page.Status != "" {
+		p += "\n" + faintStyle.Render(page.Status)
+	}
+	return p + "\n\n"
+}
+
+func (page *gffPage) GetTitle() string {
+	return page.Title
+}
@@ -0,0 +1,392 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GFFFeature is one line of a GFF3 or GTF file. Coordinates are 1-based and
+// inclusive, as in the file.
+type GFFFeature struct {
+	SeqID      string
+	Source     string
+	Type       string
+	Start      int
+	End        int
+	Score      string
+	Strand     string
+	Phase      string
+	Attributes []GFFAttribute
+	Children   []*GFFFeature
+	Parents    []*GFFFeature
+}
+
+// GFFAttribute keeps attributes in file order. Multi-valued GFF3 attributes
+// (Parent=a,b) and repeated GTF tags (tag "x"; tag "y";) both end up in Values.
+type GFFAttribute struct {
+	Key    string
+	Values []string
+}
+
+func (f *GFFFeature) Attr(key string) string {
+	for _, a := range f.Attributes {
+		if a.Key == key {
+			return strings.Join(a.Values, ",")
+		}
+	}
+	return ""
+}
+
+func (f *GFFFeature) attrValues(key string) []string {
+	for _, a := range f.Attributes {
+		if a.Key == key {
+			return a.Values
+		}
+	}
+	return nil
+}
+
+// Name picks the most readable identifier a feature has.
+func (f *GFFFeature) Name() string {
+	for _, key := range []string{"Name", "ID", "exon_id", "transcript_name", "transcript_id", "gene_name", "gene_id"} {
+		if v := f.Attr(key); v != "" {
+			return v
+		}
+	}
+	return fmt.Sprintf("%s:%d-%d", f.SeqID, f.Start, f.End)
+}
+
+type GFFFile struct {
+	Format     string // "gff3" or "gtf"
+	Directives []string
+	Features   []*GFFFeature
+	Sequences  []FastaRecord // from a ##FASTA section
+}
+
+// Roots returns features without parents, in file order.
+func (g *GFFFile) Roots() []*GFFFeature {
+	var out []*GFFFeature
+	for _, f := range g.Features {
+		if len(f.Parents) == 0 {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// SeqIDs and Types return the distinct values with their feature counts.
+func (g *GFFFile) SeqIDs() ([]string, map[string]int) {
+	return g.distinct(func(f *GFFFeature) string { return f.SeqID })
+}
+
+func (g *GFFFile) Types() ([]string, map[string]int) {
+	return g.distinct(func(f *GFFFeature) string { return f.Type })
+}
+
+func (g *GFFFile) distinct(field func(*GFFFeature) string) ([]string, map[string]int) {
+	counts := map[string]int{}
+	var keys []string
+	for _, f := range g.Features {
+		v := field(f)
+		if counts[v] == 0 {
+			keys = append(keys, v)
+		}
+		counts[v]++
+	}
+	sort.Strings(keys)
+	return keys, counts
+}
+
+// ParseGFF reads GFF3 or GTF and links features into parent/child
+// hierarchies. The dialect is told apart by the ##gff-version directive or,
+// failing that, by the attribute syntax.
+func ParseGFF(r io.Reader) (*GFFFile, error) {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 1<<16), 1<<24)
+
+	g := &GFFFile{}
+	line := 0
+	for s.Scan() {
+		line++
+		text := strings.TrimRight(s.Text(), "\r")
+		switch {
+		case text == "":
+			continue
+		case strings.HasPrefix(text, "##FASTA"):
+			recs, err := readAllFasta(s)
+			if err != nil {
+				return nil, fmt.Errorf("##FASTA section: %v", err)
+			}
+			g.Sequences = recs
+			linkGFF(g)
+			return g, nil
+		case strings.HasPrefix(text, "##"):
+			g.Directives = append(g.Directives, text)
+			if v, ok := strings.CutPrefix(text, "##gff-version"); ok {
+				if strings.TrimSpace(v) == "2" {
+					g.Format = "gtf"
+				} else {
+					g.Format = "gff3"
+				}
+			}
+			continue
+		case strings.HasPrefix(text, "#"):
+			continue
+		}
+
+		cols := strings.Split(text, "\t")
+		if len(cols) != 9 {
+			return nil, fmt.Errorf("line %d: expected 9 tab separated columns, got %d", line, len(cols))
+		}
+		if g.Format == "" {
+			g.Format = "gff3"
+			if strings.Contains(cols[8], "\"") || !strings.Contains(cols[8], "=") {
+				g.Format = "gtf"
+			}
+		}
+		f, err := parseGFFLine(cols, g.Format)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		g.Features = append(g.Features, f)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	linkGFF(g)
+	return g, nil
+}
+
+func readAllFasta(s *bufio.Scanner) ([]FastaRecord, error) {
+	var sb strings.Builder
+	for s.Scan() {
+		sb.WriteString(s.Text())
+		sb.WriteString("\n")
+	}
+	var recs []FastaRecord
+	fr := NewFastaReader(strings.NewReader(sb.String()))
+	for {
+		rec, err := fr.Read()
+		if err == io.EOF {
+			return recs, s.Err()
+		}
+		if err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+}
+
+func parseGFFLine(cols []string, format string) (*GFFFeature, error) {
+	f := &GFFFeature{
+		SeqID:  gffUnescape(cols[0]),
+		Source: gffUnescape(cols[1]),
+		Type:   gffUnescape(cols[2]),
+		Score:  cols[5],
+		Strand: cols[6],
+		Phase:  cols[7],
+	}
+	var err error
+	if f.Start, err = strconv.Atoi(cols[3]); err != nil {
+		return nil, fmt.Errorf("bad start %q", cols[3])
+	}
+	if f.End, err = strconv.Atoi(cols[4]); err != nil {
+		return nil, fmt.Errorf("bad end %q", cols[4])
+	}
+	if format == "gtf" {
+		f.Attributes = parseGTFAttributes(cols[8])
+	} else {
+		f.Attributes = parseGFF3Attributes(cols[8])
+	}
+	return f, nil
+}
+
+// gffUnescape decodes the %XX escapes GFF3 uses for reserved characters.
+func gffUnescape(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	if out, err := url.PathUnescape(s); err == nil {
+		return out
+	}
+	return s
+}
+
+func parseGFF3Attributes(col string) []GFFAttribute {
+	var attrs []GFFAttribute
+	if col == "." {
+		return nil
+	}
+	for _, pair := range strings.Split(col, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		var values []string
+		for _, v := range strings.Split(value, ",") {
+			values = append(values, gffUnescape(v))
+		}
+		attrs = append(attrs, GFFAttribute{Key: gffUnescape(key), Values: values})
+	}
+	return attrs
+}
+
+// parseGTFAttributes reads `gene_id "ENSG1"; tag "basic"; tag "CCDS";`.
+func parseGTFAttributes(col string) []GFFAttribute {
+	var attrs []GFFAttribute
+	index := map[string]int{}
+	for _, pair := range splitGTF(col) {
+		key, value, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		if idx, ok := index[key]; ok {
+			attrs[idx].Values = append(attrs[idx].Values, value)
+			continue
+		}
+		index[key] = len(attrs)
+		attrs = append(attrs, GFFAttribute{Key: key, Values: []string{value}})
+	}
+	return attrs
+}
+
+// splitGTF splits on semicolons outside quotes.
+func splitGTF(col string) []string {
+	var parts []string
+	quoted, start := false, 0
+	for idx, c := range col {
+		switch c {
+		case '"':
+			quoted = !quoted
+		case ';':
+			if !quoted {
+				parts = append(parts, col[start:idx])
+				start = idx + 1
+			}
+		}
+	}
+	return append(parts, col[start:])
+}
+
+// linkGFF builds the hierarchy. GFF3 uses ID/Parent; GTF has no explicit
+// parents, so genes, transcripts and their parts are grouped by gene_id and
+// transcript_id, with gene and transcript lines made up when missing.
+func linkGFF(g *GFFFile) {
+	if g.Format == "gtf" {
+		linkGTF(g)
+		return
+	}
+
+	byID := map[string]*GFFFeature{}
+	for _, f := range g.Features {
+		if id := f.Attr("ID"); id != "" {
+			// CDS lines may share an ID across several lines; keep the first
+			if _, ok := byID[id]; !ok {
+				byID[id] = f
+			}
+		}
+	}
+	for _, f := range g.Features {
+		for _, pid := range f.attrValues("Parent") {
+			parent, ok := byID[pid]
+			if !ok {
+				// plenty of files in the wild point at parents they never
+				// define, treat those features as roots
+				continue
+			}
+			parent.Children = append(parent.Children, f)
+			f.Parents = append(f.Parents, parent)
+		}
+	}
+}
+
+func linkGTF(g *GFFFile) {
+	genes := map[string]*GFFFeature{}
+	transcripts := map[string]*GFFFeature{}
+	var made []*GFFFeature
+
+	for _, f := range g.Features {
+		switch f.Type {
+		case "gene":
+			genes[f.Attr("gene_id")] = f
+		case "transcript":
+			transcripts[f.Attr("transcript_id")] = f
+		}
+	}
+
+	gene := func(f *GFFFeature) *GFFFeature {
+		id := f.Attr("gene_id")
+		if gn, ok := genes[id]; ok {
+			return gn
+		}
+		gn := &GFFFeature{SeqID: f.SeqID, Source: f.Source, Type: "gene", Start: f.Start, End: f.End, Strand: f.Strand, Score: ".", Phase: "."}
+		gn.Attributes = []GFFAttribute{{Key: "gene_id", Values: []string{id}}}
+		if name := f.Attr("gene_name"); name != "" {
+			gn.Attributes = append(gn.Attributes, GFFAttribute{Key: "gene_name", Values: []string{name}})
+		}
+		genes[id] = gn
+		made = append(made, gn)
+		return gn
+	}
+	transcript := func(f *GFFFeature) *GFFFeature {
+		id := f.Attr("transcript_id")
+		if tx, ok := transcripts[id]; ok {
+			return tx
+		}
+		tx := &GFFFeature{SeqID: f.SeqID, Source: f.Source, Type: "transcript", Start: f.Start, End: f.End, Strand: f.Strand, Score: ".", Phase: "."}
+		tx.Attributes = []GFFAttribute{
+			{Key: "gene_id", Values: []string{f.Attr("gene_id")}},
+			{Key: "transcript_id", Values: []string{id}},
+		}
+		transcripts[id] = tx
+		made = append(made, tx)
+		return tx
+	}
+	adopt := func(parent, child *GFFFeature) {
+		parent.Children = append(parent.Children, child)
+		child.Parents = append(child.Parents, parent)
+		parent.Start = min(parent.Start, child.Start)
+		parent.End = max(parent.End, child.End)
+	}
+
+	for _, f := range g.Features {
+		switch {
+		case f.Type == "gene":
+		case f.Type == "transcript":
+			// an exon or CDS before it may have linked it already
+			if len(f.Parents) == 0 && f.Attr("gene_id") != "" {
+				adopt(gene(f), f)
+			}
+		case f.Attr("transcript_id") != "":
+			tx := transcript(f)
+			if len(tx.Parents) == 0 && f.Attr("gene_id") != "" {
+				adopt(gene(f), tx)
+			}
+			adopt(tx, f)
+		case f.Attr("gene_id") != "":
+			adopt(gene(f), f)
+		}
+	}
+	g.Features = append(g.Features, made...)
+}
+
+// ReadGFFFile reads a GFF3 or GTF file, plain or gzipped.
+func ReadGFFFile(path string) (*GFFFile, error) {
+	f, err := OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	g, err := ParseGFF(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return g, nil
+}
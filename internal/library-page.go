@@ -0,0 +1,166 @@
+package internal
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type libraryItem struct {
+	entry LibraryEntry
+}
+
+func (i libraryItem) Title() string { return i.entry.Definition }
+
+func (i libraryItem) Description() string {
+	d := fmt.Sprintf("%s - %s - %d bp", i.entry.Accession, i.entry.MolType, i.entry.Length)
+	if i.entry.Organism != "" {
+		d += " - " + i.entry.Organism
+	}
+	return d
+}
+
+func (i libraryItem) FilterValue() string { return i.entry.text() }
+
+type libraryPage struct {
+	Title   string
+	Search  textinput.Model
+	Results list.Model
+	Shown   []LibraryEntry
+	Status  string
+
+	// what the list was last built from
+	query string
+	gen   int
+}
+
+func NewLibraryPage() *libraryPage {
+	ti := textinput.New()
+	ti.Prompt = "Search: "
+	ti.Placeholder = "accession, organism, definition or keyword"
+	ti.Width = 50
+	ti.Focus()
+
+	d := list.NewDefaultDelegate()
+	d.UpdateFunc = UpdateDelegate
+	results := list.New(nil, d, 0, 0)
+	results.SetShowTitle(false)
+	results.SetFilteringEnabled(false)
+	results.KeyMap.Quit.SetEnabled(false)
+
+	return &libraryPage{
+		Title:   "My library",
+		Search:  ti,
+		Results: results,
+		gen:     -1,
+	}
+}
+
+// refresh reruns the search when the query or the library has changed.
+func (page *libraryPage) refresh(m Model) {
+	if page.Search.Value() == page.query && m.Library.Gen == page.gen {
+		return
+	}
+	page.query = page.Search.Value()
+	page.gen = m.Library.Gen
+	page.Shown = m.Library.Search(page.query)
+
+	items := make([]list.Item, len(page.Shown))
+	for idx, e := range page.Shown {
+		items[idx] = libraryItem{entry: e}
+	}
+	page.Results.SetItems(items)
+	page.Results.ResetSelected()
+}
+
+// UpdatePage implements page.
+func (page *libraryPage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
+	page.Results.SetSize(m.Width-20, m.Height-12)
+	page.refresh(m)
+
+	switch msg := msg.(type) {
+	case alignPickedMsg:
+		page.Status = pickedStatus(msg)
+		return m, nil
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.Keys.Back) && page.Search.Value() == "":
+			m.UpdateBack(msg)
+			return m, nil
+		case msg.String() == "up", msg.String() == "down", msg.String() == "pgup", msg.String() == "pgdown":
+			var cmd tea.Cmd
+			page.Results, cmd = page.Results.Update(msg)
+			return m, cmd
+		case msg.String() == "delete":
+			item, ok := page.Results.SelectedItem().(libraryItem)
+			if !ok {
+				return m, nil
+			}
+			if err := m.Library.Remove(item.entry); err != nil {
+				page.Status = "removing failed: " + err.Error()
+			} else {
+				page.Status = "removed " + item.entry.Accession
+			}
+			page.refresh(m)
+			return m, nil
+		case key.Matches(msg, m.Keys.Pick):
+			item, ok := page.Results.SelectedItem().(libraryItem)
+			if !ok {
+				return m, nil
+			}
+			seq, err := m.Library.Load(item.entry)
+			return m, func() tea.Msg { return alignPickMsg{accession: item.entry.Accession, record: seq, err: err} }
+		case key.Matches(msg, m.Keys.Enter):
+			item, ok := page.Results.SelectedItem().(libraryItem)
+			if !ok {
+				return m, nil
+			}
+			seq, err := m.Library.Load(item.entry)
+			if err != nil {
+				page.Status = "opening failed: " + err.Error()
+				return m, nil
+			}
+			rec := NewSeqResPage(seq, item.entry.Accession, item.entry.Accession, m.Width-20, m.Height-8)
+			rec.Saved = true
+			m.Pages[LocalRecordPage] = rec
+			m.UpdateHistory(m.Page, page.Title)
+			m.Page = LocalRecordPage
+			return m, nil
+		}
+
+		var cmd tea.Cmd
+		page.Search, cmd = page.Search.Update(msg)
+		page.refresh(m)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// Page implements page.
+func (page *libraryPage) Page(m Model) string {
+	page.refresh(m)
+	p := fmt.Sprintf("Records saved with \"a\" on a detail page, stored in %s and readable offline.\n\n", m.Library.Dir)
+	if m.Library.Err != nil {
+		return p + errorStyle.Render("couldn't open the library: "+m.Library.Err.Error()) + "\n\n"
+	}
+
+	p += page.Search.View() + "\n"
+	p += faintStyle.Render(fmt.Sprintf("%d of %d records • ↑/↓: move • enter: open • del: remove • ctrl-p: pick for alignment", len(page.Shown), len(m.Library.Entries))) + "\n\n"
+	if len(m.Library.Entries) == 0 {
+		p += faintStyle.Render("the library is empty") + "\n"
+	} else {
+		p += page.Results.View()
+	}
+	if page.Status != "" {
+		p += "\n" + faintStyle.Render(page.Status)
+	}
+	return p + "\n\n"
+}
+
+func (page *libraryPage) GetTitle() string {
+	return page.Title
+}
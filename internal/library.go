@@ -0,0 +1,235 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LibraryEntry is the index line for one saved record.
+type LibraryEntry struct {
+	Database   string    `json:"database"`
+	Accession  string    `json:"accession"` // accession.version
+	Organism   string    `json:"organism,omitempty"`
+	Definition string    `json:"definition"`
+	Keywords   []string  `json:"keywords,omitempty"`
+	MolType    string    `json:"moltype,omitempty"`
+	Length     int       `json:"length"`
+	UpdateDate string    `json:"update_date,omitempty"`
+	Added      time.Time `json:"added"`
+	File       string    `json:"file"` // relative to the library directory
+}
+
+// text is what full-text search looks at.
+func (e LibraryEntry) text() string {
+	return strings.ToLower(strings.Join([]string{e.Accession, e.Organism, e.Definition, strings.Join(e.Keywords, " ")}, " "))
+}
+
+// Library keeps full records (metadata, features and sequence) on disk so
+// they can be browsed without a connection. Records live as JSON under
+// records/, and index.json lists them.
+type Library struct {
+	Dir     string
+	Entries []LibraryEntry // newest first
+	Err     error          // set if the index couldn't be loaded
+	Gen     int            // bumped on every add or remove
+}
+
+const libraryIndex = "index.json"
+
+// LibraryDir is where the library lives: $BIODATA_LIBRARY if set, otherwise
+// a biodata directory in the platform's user data directory.
+func LibraryDir() (string, error) {
+	if dir := os.Getenv("BIODATA_LIBRARY"); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "biodata"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", "biodata"), nil
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return filepath.Join(dir, "biodata"), nil
+		}
+	}
+	return filepath.Join(home, ".local", "share", "biodata"), nil
+}
+
+// NewLibrary opens the library in LibraryDir. A missing library is just an
+// empty one; other problems are kept in Err for the library page to show.
+func NewLibrary() *Library {
+	dir, err := LibraryDir()
+	if err != nil {
+		return &Library{Err: err}
+	}
+	lib, err := OpenLibrary(dir)
+	if err != nil {
+		return &Library{Dir: dir, Err: err}
+	}
+	return lib
+}
+
+func OpenLibrary(dir string) (*Library, error) {
+	lib := &Library{Dir: dir}
+	data, err := os.ReadFile(filepath.Join(dir, libraryIndex))
+	if errors.Is(err, os.ErrNotExist) {
+		return lib, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &lib.Entries); err != nil {
+		return nil, fmt.Errorf("%s: %v", filepath.Join(dir, libraryIndex), err)
+	}
+	return lib, nil
+}
+
+// save rewrites the index, through a temporary file so a crash never
+// leaves it half written.
+func (lib *Library) save() error {
+	data, err := json.MarshalIndent(lib.Entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(lib.Dir, libraryIndex)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+func (lib *Library) find(database, accession string) int {
+	for idx, e := range lib.Entries {
+		if e.Database == database && e.Accession == accession {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (lib *Library) Contains(database string, seq GBSeq) bool {
+	return lib.find(database, CartItemFromSeq(database, seq).Accession) >= 0
+}
+
+// Add saves a full record, replacing an earlier copy of the same version.
+func (lib *Library) Add(database string, seq GBSeq) error {
+	if lib.Dir == "" {
+		return errors.New("no library directory")
+	}
+	item := CartItemFromSeq(database, seq)
+	if item.Accession == "" {
+		return errors.New("record has no accession")
+	}
+	if len(seq.Sequence) < seq.Length {
+		return fmt.Errorf("%s is missing its sequence", item.Accession)
+	}
+
+	file := filepath.Join("records", database, item.Accession+".json")
+	if err := os.MkdirAll(filepath.Join(lib.Dir, filepath.Dir(file)), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(seq)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(lib.Dir, file), data, 0644); err != nil {
+		return err
+	}
+
+	entry := LibraryEntry{
+		Database:   database,
+		Accession:  item.Accession,
+		Organism:   seq.Organism,
+		Definition: seq.Definition,
+		Keywords:   seq.Keywords,
+		MolType:    seq.MolType,
+		Length:     seq.Length,
+		UpdateDate: seq.UpdateDate,
+		Added:      time.Now().UTC(),
+		File:       file,
+	}
+	if idx := lib.find(database, item.Accession); idx >= 0 {
+		lib.Entries = append(lib.Entries[:idx], lib.Entries[idx+1:]...)
+	}
+	lib.Entries = append([]LibraryEntry{entry}, lib.Entries...)
+	lib.Gen++
+	return lib.save()
+}
+
+// Remove deletes an entry and its record file.
+func (lib *Library) Remove(entry LibraryEntry) error {
+	idx := lib.find(entry.Database, entry.Accession)
+	if idx < 0 {
+		return nil
+	}
+	lib.Entries = append(lib.Entries[:idx], lib.Entries[idx+1:]...)
+	lib.Gen++
+	if err := lib.save(); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(lib.Dir, entry.File))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Load reads a saved record back.
+func (lib *Library) Load(entry LibraryEntry) (GBSeq, error) {
+	var seq GBSeq
+	data, err := os.ReadFile(filepath.Join(lib.Dir, entry.File))
+	if err != nil {
+		return seq, err
+	}
+	err = json.Unmarshal(data, &seq)
+	return seq, err
+}
+
+// Search returns the entries containing every word of the query in their
+// accession, organism, definition or keywords. Accession matches come
+// first; otherwise the library's newest-first order is kept.
+func (lib *Library) Search(query string) []LibraryEntry {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return lib.Entries
+	}
+
+	var hits []LibraryEntry
+	var byAccession []bool
+	for _, e := range lib.Entries {
+		text := e.text()
+		match := true
+		for _, t := range terms {
+			if !strings.Contains(text, t) {
+				match = false
+				break
+			}
+		}
+		if match {
+			hits = append(hits, e)
+			byAccession = append(byAccession, strings.HasPrefix(strings.ToLower(e.Accession), terms[0]))
+		}
+	}
+	idx := make([]int, len(hits))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return byAccession[idx[a]] && !byAccession[idx[b]] })
+	out := make([]LibraryEntry, len(hits))
+	for i, j := range idx {
+		out[i] = hits[j]
+	}
+	return out
+}
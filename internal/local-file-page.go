@@ -0,0 +1,511 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+type localFilePage struct {
+	Title    string
+	Input    textinput.Model
+	Path     string
+	Entries  []FastaEntry
+	Records  []GBSeq // set instead of Entries for GenBank and EMBL files
+	Format   string
+	Index    *FastaIndex // random access for FASTA, once indexed
+	Region   textinput.Model
+	Jumping  bool
+	Convert  convertPrompt
+	Status   string
+	Results  list.Model
+	Spinner  spinner.Model
+	Loading  bool
+	Received bool
+	Err      error
+}
+
+func NewLocalFilePage() *localFilePage {
+	ti := textinput.New()
+	ti.Placeholder = "path/to/sequences.fasta, .gb or .embl"
+	ti.Focus()
+	ti.CharLimit = 512
+	ti.Width = 50
+
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+
+	region := textinput.New()
+	region.Prompt = "Go to: "
+	region.Placeholder = "chr1:10000-20000"
+	region.Width = 40
+
+	return &localFilePage{
+		Title:   "Local file",
+		Input:   ti,
+		Region:  region,
+		Convert: newConvertPrompt(),
+		Spinner: s,
+	}
+}
+
+type localFileMsg struct {
+	path    string
+	format  string
+	entries []FastaEntry
+	records []GBSeq
+	index   *FastaIndex
+}
+
+type localFastqMsg struct {
+	path string
+}
+
+type localGFFMsg struct {
+	path string
+	file *GFFFile
+}
+
+type localBEDMsg struct {
+	path string
+	file *BEDFile
+}
+
+type localRecordMsg struct {
+	record GBSeq
+	note   string // shown on the record's page
+}
+
+// localRegionMsg is a record cut out of an indexed FASTA file.
+type localRegionMsg struct {
+	index  *FastaIndex
+	record GBSeq
+	note   string
+}
+
+// sequences longer than this open as just their start, since the detail
+// view lays out the whole sequence
+const localPreviewLength = 10_000
+
+// firstLine returns the first non-blank line of a file.
+func firstLine(path string) (string, error) {
+	f, err := OpenFile(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if line := strings.TrimSpace(s.Text()); line != "" {
+			return line, nil
+		}
+	}
+	return "", s.Err()
+}
+
+func scanLocalFile(path string) func() tea.Msg {
+	return func() tea.Msg {
+		line, err := firstLine(path)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		// flat files are small enough to parse up front, FASTA may not be
+		var records []GBSeq
+		switch {
+		case strings.HasPrefix(line, "LOCUS"):
+			records, err = ReadGenBankFile(path)
+			if err != nil {
+				return errMsg{err: err}
+			}
+			return localFileMsg{path: path, format: "genbank", records: records}
+		case strings.HasPrefix(line, "@"):
+			return localFastqMsg{path: path}
+		case strings.HasPrefix(line, "##gff-version") || strings.HasPrefix(line, "#!") || strings.Count(line, "\t") == 8:
+			g, err := ReadGFFFile(path)
+			if err != nil {
+				return errMsg{err: err}
+			}
+			return localGFFMsg{path: path, file: g}
+		case isBEDLine(line):
+			b, err := ReadBEDFile(path)
+			if err != nil {
+				return errMsg{err: err}
+			}
+			return localBEDMsg{path: path, file: b}
+		case strings.HasPrefix(line, "ID   "):
+			records, err = ReadEMBLFile(path)
+			if err != nil {
+				return errMsg{err: err}
+			}
+			return localFileMsg{path: path, format: "embl", records: records}
+		}
+		// an existing .fai lists a genome without reading it
+		if _, err := os.Stat(path + FaiSuffix); err == nil {
+			if idx, err := OpenFastaIndex(path); err == nil {
+				return localFileMsg{path: path, format: "fasta", entries: idx.FastaEntries(), index: idx}
+			}
+		}
+		entries, err := ScanFastaFile(path)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return localFileMsg{path: path, format: "fasta", entries: entries}
+	}
+}
+
+// isBEDLine spots BED and bedGraph: track lines, or a name followed by two
+// integer coordinates.
+func isBEDLine(line string) bool {
+	if strings.HasPrefix(line, "track") || strings.HasPrefix(line, "browser") {
+		return true
+	}
+	cols := strings.Split(line, "\t")
+	if len(cols) < 3 {
+		return false
+	}
+	for _, col := range cols[1:3] {
+		if _, err := strconv.Atoi(col); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func readLocalRecord(path string, offset int64) func() tea.Msg {
+	return func() tea.Msg {
+		rec, length, err := ReadFastaRecordAt(path, offset, localPreviewLength)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		var note string
+		if length > localPreviewLength {
+			note = fmt.Sprintf("showing the first %d of %d bp, press r on the file list for another region", localPreviewLength, length)
+		}
+		return localRecordMsg{record: rec.GBSeq(), note: note}
+	}
+}
+
+// readLocalRegion fetches a region through the file's index, building the
+// index first if there isn't one yet.
+func readLocalRegion(path string, idx *FastaIndex, region string) func() tea.Msg {
+	return func() tea.Msg {
+		r, err := ParseRegion(region)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		if idx == nil {
+			if idx, err = OpenFastaIndex(path); err != nil {
+				return errMsg{err: err}
+			}
+		}
+		var note string
+		// a long sequence opens as a preview, whether asked for by name or
+		// from a start with no end
+		if e, ok := idx.Entry(r.Chrom); ok && r.End == 0 && e.Length-int64(max(r.Start, 1)) >= localPreviewLength {
+			r.Start = max(r.Start, 1)
+			r.End = r.Start + localPreviewLength - 1
+			note = fmt.Sprintf("showing %s of %d bp, press r on the file list for another region", r, e.Length)
+		}
+		rec, err := idx.FetchRecord(r)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return localRegionMsg{index: idx, record: rec.GBSeq(), note: note}
+	}
+}
+
+// convertPrompt asks which format to convert the open file to.
+type convertPrompt struct {
+	Input  textinput.Model
+	Active bool
+}
+
+type convertedMsg struct {
+	path    string
+	from    string
+	records int
+	err     error
+}
+
+func newConvertPrompt() convertPrompt {
+	ti := textinput.New()
+	ti.Prompt = "Convert to: "
+	ti.Placeholder = "genbank, embl, fasta, gff3, json or xml"
+	ti.Width = 40
+	return convertPrompt{Input: ti}
+}
+
+func (c *convertPrompt) Start() tea.Cmd {
+	c.Active = true
+	c.Input.SetValue("")
+	return c.Input.Focus()
+}
+
+// Update handles keys while the prompt is open. On enter it converts path
+// into the download directory.
+func (c *convertPrompt) Update(msg tea.KeyMsg, path string, m Model) tea.Cmd {
+	switch msg.String() {
+	case "enter":
+		c.Active = false
+		c.Input.Blur()
+		to, err := FindConvertFormat(strings.TrimSpace(c.Input.Value()))
+		if err != nil {
+			return func() tea.Msg { return convertedMsg{err: err} }
+		}
+		out := ConvertedName(path, m.Downloads.Dir, to)
+		overwrite := m.Downloads.Overwrite
+		return func() tea.Msg {
+			from, n, err := ConvertFile(path, out, "", to.Name, overwrite)
+			return convertedMsg{path: out, from: from, records: n, err: err}
+		}
+	case "backspace":
+		if c.Input.Value() == "" {
+			c.Active = false
+			c.Input.Blur()
+			return nil
+		}
+	}
+	var cmd tea.Cmd
+	c.Input, cmd = c.Input.Update(msg)
+	return cmd
+}
+
+func (msg convertedMsg) String() string {
+	if msg.err != nil {
+		return "conversion failed: " + msg.err.Error()
+	}
+	return fmt.Sprintf("converted %d records from %s → %s", msg.records, msg.from, msg.path)
+}
+
+func FastaEntriesToItems(entries []FastaEntry) []list.Item {
+	out := make([]list.Item, len(entries))
+	for idx, e := range entries {
+		title := e.Description
+		if title == "" {
+			title = e.ID
+		}
+		out[idx] = ListItem{
+			title: title,
+			desc:  fmt.Sprintf("%s - %d", e.ID, e.Length),
+			index: idx,
+		}
+	}
+	return out
+}
+
+// UpdatePage implements page.
+func (page *localFilePage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if page.Jumping {
+			switch msg.String() {
+			case "enter":
+				page.Jumping = false
+				page.Region.Blur()
+				page.Loading = true
+				page.Err = nil
+				return m, tea.Batch(
+					page.Spinner.Tick,
+					readLocalRegion(page.Path, page.Index, page.Region.Value()),
+				)
+			case "backspace":
+				if page.Region.Value() == "" {
+					page.Jumping = false
+					page.Region.Blur()
+					return m, nil
+				}
+			}
+			var cmd tea.Cmd
+			page.Region, cmd = page.Region.Update(msg)
+			return m, cmd
+		}
+
+		if page.Convert.Active {
+			return m, page.Convert.Update(msg, page.Path, m)
+		}
+
+		switch {
+		case msg.String() == "c" && page.Received && page.Results.FilterState() != list.Filtering:
+			return m, page.Convert.Start()
+		case msg.String() == "r" && page.Received && page.Format == "fasta" &&
+			page.Results.FilterState() != list.Filtering:
+			page.Jumping = true
+			return m, page.Region.Focus()
+		case key.Matches(msg, m.Keys.Enter):
+			if page.Received || page.Loading {
+				break
+			}
+			page.Loading = true
+			page.Err = nil
+			return m, tea.Batch(
+				page.Spinner.Tick,
+				scanLocalFile(page.Input.Value()),
+			)
+		case key.Matches(msg, m.Keys.Back):
+			if page.Input.Value() == "" && !page.Received {
+				m.UpdateBack(msg)
+			}
+			if page.Received && page.Results.FilterState() == list.Unfiltered {
+				page.Received = false
+				page.Input.Focus()
+				return m, nil
+			}
+		}
+
+	case localFileMsg:
+		page.Loading = false
+		page.Path = msg.path
+		page.Entries = msg.entries
+		page.Records = msg.records
+		page.Format = msg.format
+		page.Index = msg.index
+
+		items := FastaEntriesToItems(msg.entries)
+		if msg.records != nil {
+			items = make([]list.Item, len(msg.records))
+			for idx, rec := range msg.records {
+				item := seqSummaryItem(rec.AccessionVersion, rec)
+				item.index = idx
+				items[idx] = item
+			}
+		}
+
+		d := list.NewDefaultDelegate()
+		d.UpdateFunc = UpdateDelegate
+		page.Results = list.New(items, d, m.Width-20, m.Height-8)
+		page.Results.Title = fmt.Sprintf("%s (%d records)", filepath.Base(msg.path), len(items))
+		m.ShowHelp = false
+		page.Received = true
+
+	case localFastqMsg:
+		page.Loading = false
+		fq := NewFastqPage(msg.path, m.Width-20, m.Height-8)
+		m.Pages[FastqPage] = fq
+		m.UpdateHistory(m.Page, page.Title)
+		m.Page = FastqPage
+		return m, fq.Start()
+
+	case localGFFMsg:
+		page.Loading = false
+		m.Pages[GFFPage] = NewGFFPage(msg.path, msg.file, m.Width-20, m.Height-8)
+		m.UpdateHistory(m.Page, page.Title)
+		m.Page = GFFPage
+		return m, nil
+
+	case localBEDMsg:
+		page.Loading = false
+		m.Pages[BEDPage] = NewBEDPage(msg.path, msg.file, m.Width-20, m.Height-8)
+		m.UpdateHistory(m.Page, page.Title)
+		m.Page = BEDPage
+		return m, nil
+
+	case listSelectMsg:
+		// msg.Index counts only the items left by a filter
+		i := itemIndex(&page.Results)
+		if i < len(page.Records) {
+			return m, func() tea.Msg {
+				return localRecordMsg{record: page.Records[i]}
+			}
+		}
+		if i < len(page.Entries) && page.Entries[i].Offset < 0 {
+			page.Loading = true
+			return m, tea.Batch(
+				page.Spinner.Tick,
+				readLocalRegion(page.Path, page.Index, page.Entries[i].ID),
+			)
+		}
+		if i < len(page.Entries) {
+			page.Loading = true
+			return m, tea.Batch(
+				page.Spinner.Tick,
+				readLocalRecord(page.Path, page.Entries[i].Offset),
+			)
+		}
+
+	case localRecordMsg:
+		page.Loading = false
+		rec := NewLocalSeqResPage(msg.record, page.Path, page.Format, m.Width-20, m.Height-8)
+		rec.Status = msg.note
+		m.Pages[LocalRecordPage] = rec
+		m.UpdateHistory(m.Page, page.Title)
+		m.Page = LocalRecordPage
+		return m, nil
+
+	case convertedMsg:
+		page.Status = msg.String()
+		return m, nil
+
+	case localRegionMsg:
+		page.Index = msg.index
+		return page.UpdatePage(localRecordMsg{record: msg.record, note: msg.note}, m)
+
+	case errMsg:
+		page.Loading = false
+		page.Err = msg.err
+
+	case tea.WindowSizeMsg:
+		if page.Received {
+			page.Results.SetSize(msg.Width-20, msg.Height-8)
+		}
+	}
+
+	var cmd tea.Cmd
+	if page.Loading {
+		page.Spinner, cmd = page.Spinner.Update(msg)
+	} else if page.Received {
+		page.Results, cmd = page.Results.Update(msg)
+	} else {
+		page.Input, cmd = page.Input.Update(msg)
+	}
+	return m, cmd
+}
+
+// Page implements page.
+func (page *localFilePage) Page(m Model) string {
+	p := "Open a FASTA, GenBank or EMBL file from disk and browse its records, a FASTQ file (plain or gzipped) for a read quality summary, GFF3/GTF annotation, or BED/bedGraph intervals.\n\n\n"
+
+	if page.Loading {
+		p += page.Spinner.View() + " Reading file ... "
+	} else if page.Received {
+		help := "c: convert file"
+		if page.Format == "fasta" {
+			help += " • r: go to region (indexes the file on first use)"
+		}
+		switch {
+		case page.Jumping:
+			p += page.Region.View() + "\n"
+		case page.Convert.Active:
+			p += page.Convert.Input.View() + "\n"
+		default:
+			p += faintStyle.Render(help) + "\n"
+		}
+		p += page.Results.View()
+		if page.Status != "" {
+			p += "\n" + faintStyle.Render(page.Status)
+		}
+	} else {
+		p += page.Input.View()
+	}
+	if page.Err != nil {
+		p += "\n\n" + errorStyle.Render(page.Err.Error())
+	}
+
+	p += "\n\n"
+	return p
+}
+
+func (page *localFilePage) GetTitle() string {
+	return page.Title
+}
@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseLocation turns an INSDC feature location such as
+// "join(12..78,complement(<134..>202))" into intervals. Fuzzy ends are
+// treated as exact, and complemented intervals come out with From > To.
+func ParseLocation(loc string) ([]GBInterval, error) {
+	loc = strings.ReplaceAll(loc, " ", "")
+	return parseLocation(loc, "")
+}
+
+func parseLocation(loc, acc string) ([]GBInterval, error) {
+	if inner, ok := unwrapLocation(loc, "complement"); ok {
+		ivs, err := parseLocation(inner, acc)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]GBInterval, len(ivs))
+		for idx, iv := range ivs {
+			iv.From, iv.To = iv.To, iv.From
+			out[len(ivs)-1-idx] = iv
+		}
+		return out, nil
+	}
+	for _, op := range []string{"join", "order"} {
+		if inner, ok := unwrapLocation(loc, op); ok {
+			var out []GBInterval
+			for _, part := range splitLocation(inner) {
+				ivs, err := parseLocation(part, acc)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, ivs...)
+			}
+			return out, nil
+		}
+	}
+
+	// remote reference, e.g. J00194.1:100..202
+	if a, rest, ok := strings.Cut(loc, ":"); ok {
+		return parseLocation(rest, a)
+	}
+
+	iv := GBInterval{Accession: acc}
+	var from, to string
+	switch {
+	case strings.Contains(loc, ".."):
+		from, to, _ = strings.Cut(loc, "..")
+	case strings.Contains(loc, "^"):
+		from, to, _ = strings.Cut(loc, "^")
+	case strings.Contains(loc, "."):
+		// (a.b) means a single base somewhere between a and b
+		from, to, _ = strings.Cut(strings.Trim(loc, "()"), ".")
+	default:
+		from, to = loc, loc
+	}
+	var err error
+	if iv.From, err = strconv.Atoi(strings.Trim(from, "<>()")); err != nil {
+		return nil, fmt.Errorf("bad location %q", loc)
+	}
+	if iv.To, err = strconv.Atoi(strings.Trim(to, "<>()")); err != nil {
+		return nil, fmt.Errorf("bad location %q", loc)
+	}
+	if iv.From == iv.To {
+		iv.Point = iv.From
+	}
+	return []GBInterval{iv}, nil
+}
+
+func unwrapLocation(loc, op string) (string, bool) {
+	if strings.HasPrefix(loc, op+"(") && strings.HasSuffix(loc, ")") {
+		return loc[len(op)+1 : len(loc)-1], true
+	}
+	return "", false
+}
+
+// splitLocation splits on commas that aren't inside parentheses.
+func splitLocation(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for idx, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:idx])
+				start = idx + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// FormatLocation is the reverse of ParseLocation, without the fuzzy ends:
+// intervals all on the minus strand come out as complement(join(...)).
+func FormatLocation(ivs []GBInterval) string {
+	span := func(iv GBInterval) string {
+		from, to := min(iv.From, iv.To), max(iv.From, iv.To)
+		s := fmt.Sprintf("%d..%d", from, to)
+		if from == to {
+			s = strconv.Itoa(from)
+		}
+		if iv.Accession != "" {
+			s = iv.Accession + ":" + s
+		}
+		return s
+	}
+	join := func(parts []string) string {
+		if len(parts) == 1 {
+			return parts[0]
+		}
+		return "join(" + strings.Join(parts, ",") + ")"
+	}
+
+	minus := len(ivs) > 0
+	for _, iv := range ivs {
+		if iv.From <= iv.To {
+			minus = false
+		}
+	}
+	parts := make([]string, len(ivs))
+	if minus {
+		for idx, iv := range ivs {
+			parts[len(ivs)-1-idx] = span(iv)
+		}
+		return "complement(" + join(parts) + ")"
+	}
+	for idx, iv := range ivs {
+		parts[idx] = span(iv)
+		if iv.From > iv.To {
+			parts[idx] = "complement(" + parts[idx] + ")"
+		}
+	}
+	return join(parts)
+}
@@ -15,6 +15,26 @@ var (
 	mainStyle   = lipgloss.NewStyle().MarginLeft(2)
 )
 
+// pages reachable from anywhere in the app
+const (
+	DownloadsPage = 900
+	CartPage      = 901
+	LocalFilePage = 902
+
+	// detail page for whichever local record was opened last
+	LocalRecordPage = 903
+	FastqPage       = 904
+	GFFPage         = 905
+	BEDPage         = 906
+	LibraryPage     = 907
+	ORFPage         = 908
+	RestrictionPage = 909
+	AlignPage       = 910
+	PrimerPage      = 911
+	CodonPage       = 912
+	DotPlotPage     = 913
+)
+
 type Page interface {
 	UpdatePage(tea.Msg, Model) (tea.Model, tea.Cmd)
 	Page(Model) string
@@ -30,6 +50,9 @@ type Model struct {
 	Keys          keyMap
 	ShowHelp      bool
 	Help          help.Model
+	Downloads     *DownloadManager
+	Cart          *Cart
+	Library       *Library
 	Height        int
 	Width         int
 }
@@ -38,15 +61,21 @@ type Model struct {
 // keyMap defines a set of keybindings. To work for help it must satisfy
 // key.Map. It could also very easily be a map[string]key.Binding.
 type keyMap struct {
-	Up    key.Binding
-	Down  key.Binding
-	Left  key.Binding
-	Right key.Binding
-	Dl    key.Binding
-	Back  key.Binding
-	Enter key.Binding
-	Help  key.Binding
-	Quit  key.Binding
+	Up     key.Binding
+	Down   key.Binding
+	Left   key.Binding
+	Right  key.Binding
+	Dl     key.Binding
+	Dls    key.Binding
+	Export key.Binding
+	Save   key.Binding
+	Mark   key.Binding
+	Cart   key.Binding
+	Pick   key.Binding
+	Back   key.Binding
+	Enter  key.Binding
+	Help   key.Binding
+	Quit   key.Binding
 }
 
 // ShortHelp returns keybindings to be shown in the mini help view. It's part
@@ -62,6 +91,8 @@ func (k keyMap) FullHelp() [][]key.Binding {
 		{k.Up, k.Down}, // these are columns
 		{k.Left, k.Right},
 		{k.Back, k.Enter},
+		{k.Dl, k.Dls, k.Export, k.Save},
+		{k.Mark, k.Cart, k.Pick},
 		{k.Help, k.Quit},
 	}
 }
@@ -87,6 +118,30 @@ var Keys = keyMap{
 		key.WithKeys("d"),
 		key.WithHelp("d", "download current sequence"),
 	),
+	Dls: key.NewBinding(
+		key.WithKeys("ctrl+o"),
+		key.WithHelp("ctrl-o", "show downloads"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "export record as EMBL/GenBank"),
+	),
+	Save: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "save record to library"),
+	),
+	Mark: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "add/remove from cart"),
+	),
+	Cart: key.NewBinding(
+		key.WithKeys("ctrl+t"),
+		key.WithHelp("ctrl-t", "show cart"),
+	),
+	Pick: key.NewBinding(
+		key.WithKeys("ctrl+p"),
+		key.WithHelp("ctrl-p", "pick for alignment"),
+	),
 	Back: key.NewBinding(
 		key.WithKeys("backspace"),
 		key.WithHelp("bksp", "previous page"),
@@ -130,6 +185,53 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.Help.ShowAll = !m.Help.ShowAll
 		}
 
+		if key.Matches(msg, m.Keys.Dls) && m.Page != DownloadsPage {
+			m.UpdateHistory(m.Page, m.Pages[m.Page].GetTitle())
+			m.Page = DownloadsPage
+			return m, nil
+		}
+		if key.Matches(msg, m.Keys.Cart) && m.Page != CartPage {
+			m.UpdateHistory(m.Page, m.Pages[m.Page].GetTitle())
+			m.Page = CartPage
+			return m, nil
+		}
+
+	// downloads run in the background, whatever page is showing
+	case downloadProgressMsg:
+		return m, m.Downloads.Progress(msg)
+	case downloadDoneMsg:
+		return m, m.Downloads.Done(msg)
+
+	// a record fetched for the library is saved wherever the user is now
+	case librarySaveMsg:
+		err := msg.err
+		if err == nil {
+			err = m.Library.Add(msg.database, msg.record)
+		}
+		return m.Pages[m.Page].UpdatePage(librarySavedMsg{accession: msg.accession, err: err}, m)
+
+	// picking the second record for an alignment goes straight to it
+	case alignPickMsg:
+		align := m.Pages[AlignPage].(*alignPage)
+		if msg.err == nil {
+			align.pick(msg.record)
+			if len(align.Records) == 2 && m.Page != AlignPage {
+				m.UpdateHistory(m.Page, m.Pages[m.Page].GetTitle())
+				m.Page = AlignPage
+				return m, align.align()
+			}
+		}
+		return m.Pages[m.Page].UpdatePage(alignPickedMsg{accession: msg.accession, picked: len(align.Records), err: msg.err}, m)
+
+	// an alignment finishing while the user is elsewhere is kept for later
+	case alignedMsg:
+		return m.Pages[AlignPage].UpdatePage(msg, m)
+	case dotPlotMsg:
+		return msg.page.UpdatePage(msg, m)
+	// so is a sequence loaded for a record the user has left
+	case sequenceMsg:
+		return msg.page.UpdatePage(msg, m)
+
 	}
 	// update the page
 	return m.Pages[m.Page].UpdatePage(msg, m)
@@ -0,0 +1,285 @@
+package internal
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+type MotifKind int
+
+const (
+	MotifIUPAC   MotifKind = iota // degenerate bases (TATAWAWR) or residues
+	MotifPROSITE                  // C-x(2,4)-C-x(3)-[LIVMFYWC]-x(8)-H-x(3,5)-H
+	MotifRegex                    // anything else, or /between slashes/
+)
+
+func (k MotifKind) String() string {
+	return [...]string{"IUPAC", "PROSITE", "regex"}[k]
+}
+
+// MotifHitLimit caps the hits collected per strand.
+var MotifHitLimit = 10_000
+
+type Motif struct {
+	Pattern string
+	Kind    MotifKind
+	Expr    string // the pattern as a Go regexp
+	re      *regexp.Regexp
+	atStart bool // PROSITE <, only at the N-terminus
+}
+
+type MotifHit struct {
+	Start, End int    // 1-based, inclusive, on the forward strand
+	Strand     string // + or -, empty for proteins
+	Match      string // as read on its own strand
+}
+
+// CompileMotif works out what kind of pattern it's been given and turns it
+// into a regexp: slashes mean regex, dashes, braces or repeat counts mean
+// PROSITE, and a plain string of ambiguity codes means IUPAC. Anything that
+// turns out not to be PROSITE or IUPAC is tried as a regex.
+func CompileMotif(pattern string, protein bool) (*Motif, error) {
+	pattern = strings.TrimSpace(pattern)
+	mo := &Motif{Pattern: pattern}
+	var err error
+	switch {
+	case pattern == "":
+		return nil, fmt.Errorf("empty pattern")
+	case len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/"):
+		mo.Kind, mo.Expr = MotifRegex, "(?i)"+pattern[1:len(pattern)-1]
+	case strings.ContainsAny(pattern, "-{<>") || strings.Contains(pattern, "x("):
+		mo.Kind = MotifPROSITE
+		if mo.Expr, mo.atStart, err = prositeRegexp(pattern); err != nil {
+			if _, rerr := regexp.Compile("(?i)" + pattern); rerr == nil {
+				mo.Kind, mo.Expr, mo.atStart, err = MotifRegex, "(?i)"+pattern, false, nil
+			}
+		}
+	default:
+		mo.Kind = MotifIUPAC
+		if mo.Expr, err = iupacRegexp(pattern, protein); err != nil {
+			mo.Kind, mo.Expr, err = MotifRegex, "(?i)"+pattern, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if mo.re, err = regexp.Compile(mo.Expr); err != nil {
+		return nil, fmt.Errorf("bad pattern: %v", err)
+	}
+	return mo, nil
+}
+
+var aminoAmbiguous = map[byte]string{'X': ".", 'B': "[DN]", 'Z': "[EQ]", 'J': "[IL]"}
+
+// iupacRegexp expands ambiguity codes into character classes. Degenerate
+// positions match concrete bases, so an N in the sequence only matches an N
+// in the pattern.
+func iupacRegexp(p string, protein bool) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := upper(p[i])
+		if protein {
+			if c < 'A' || c > 'Z' {
+				return "", fmt.Errorf("%q is not an amino acid", c)
+			}
+			if class, ok := aminoAmbiguous[c]; ok {
+				sb.WriteString(class)
+			} else {
+				sb.WriteByte(c)
+			}
+			continue
+		}
+		bases := iupacBases[c]
+		switch {
+		case bases == "":
+			return "", fmt.Errorf("%q is not an IUPAC base", c)
+		case c == 'N':
+			sb.WriteString("[ACGTN]")
+		case len(bases) == 1:
+			sb.WriteString(bases)
+		default:
+			sb.WriteString("[" + bases + "]")
+		}
+	}
+	return sb.String(), nil
+}
+
+// prositeRegexp translates PROSITE syntax: elements joined by dashes, x for
+// any residue, [..] for any of, {..} for none of, (n) or (n,m) repeats, and
+// < or > to anchor at either terminus.
+func prositeRegexp(p string) (expr string, atStart bool, err error) {
+	p = strings.TrimSuffix(strings.ToUpper(p), ".")
+	if strings.HasPrefix(p, "<") {
+		atStart, p = true, p[1:]
+	}
+	var atEnd bool
+	if strings.HasSuffix(p, ">") {
+		atEnd, p = true, p[:len(p)-1]
+	}
+
+	var sb strings.Builder
+	if atStart {
+		sb.WriteString("^")
+	}
+	for _, el := range strings.Split(p, "-") {
+		core, repeat, _ := strings.Cut(el, "(")
+		switch {
+		case core == "X":
+			sb.WriteString(".")
+		case len(core) == 1 && core[0] >= 'A' && core[0] <= 'Z':
+			sb.WriteString(core)
+		case len(core) > 2 && core[0] == '[' && core[len(core)-1] == ']':
+			// [G>] means G or the C-terminus; the terminus isn't matched here
+			sb.WriteString("[" + strings.Trim(core[1:len(core)-1], "<>") + "]")
+		case len(core) > 2 && core[0] == '{' && core[len(core)-1] == '}':
+			sb.WriteString("[^" + core[1:len(core)-1] + "]")
+		default:
+			return "", false, fmt.Errorf("bad PROSITE element %q", el)
+		}
+		if repeat != "" {
+			counts := strings.Split(strings.TrimSuffix(repeat, ")"), ",")
+			for _, n := range counts {
+				if _, err := strconv.Atoi(n); err != nil || len(counts) > 2 {
+					return "", false, fmt.Errorf("bad PROSITE repeat %q", el)
+				}
+			}
+			sb.WriteString("{" + strings.Join(counts, ",") + "}")
+		}
+	}
+	if atEnd {
+		sb.WriteString("$")
+	}
+	return sb.String(), atStart, nil
+}
+
+// find lists where the motif matches s as [start, end) pairs. IUPAC and
+// PROSITE hits may overlap (AA twice in AAA); regex hits don't, as usual.
+func (mo *Motif) find(s string) [][2]int {
+	var out [][2]int
+	if mo.Kind == MotifRegex {
+		for _, loc := range mo.re.FindAllStringIndex(s, MotifHitLimit) {
+			if loc[1] > loc[0] {
+				out = append(out, [2]int{loc[0], loc[1]})
+			}
+		}
+		return out
+	}
+	for pos := 0; pos < len(s) && len(out) < MotifHitLimit; {
+		loc := mo.re.FindStringIndex(s[pos:])
+		if loc == nil {
+			break
+		}
+		start, end := pos+loc[0], pos+loc[1]
+		if end > start {
+			out = append(out, [2]int{start, end})
+		}
+		if mo.atStart {
+			break
+		}
+		pos = start + 1
+	}
+	return out
+}
+
+// Search finds the motif in a protein, or on both strands of a nucleotide
+// sequence. A palindromic site found on both strands is listed once, as +.
+func (mo *Motif) Search(seq string, protein bool) []MotifHit {
+	upperSeq := strings.ToUpper(seq)
+	if protein {
+		var hits []MotifHit
+		for _, loc := range mo.find(upperSeq) {
+			hits = append(hits, MotifHit{Start: loc[0] + 1, End: loc[1], Match: upperSeq[loc[0]:loc[1]]})
+		}
+		return hits
+	}
+
+	dna := strings.ReplaceAll(upperSeq, "U", "T")
+	n := len(dna)
+	var hits []MotifHit
+	seen := map[[2]int]bool{}
+	for _, loc := range mo.find(dna) {
+		hits = append(hits, MotifHit{Start: loc[0] + 1, End: loc[1], Strand: "+", Match: upperSeq[loc[0]:loc[1]]})
+		seen[[2]int{loc[0] + 1, loc[1]}] = true
+	}
+	for _, loc := range mo.find(ReverseComplement(dna)) {
+		start, end := n-loc[1]+1, n-loc[0]
+		if seen[[2]int{start, end}] {
+			continue
+		}
+		hits = append(hits, MotifHit{Start: start, End: end, Strand: "-", Match: ReverseComplement(upperSeq[start-1 : end])})
+	}
+	slices.SortStableFunc(hits, func(a, b MotifHit) int { return a.Start - b.Start })
+	return hits
+}
+
+var (
+	hitStyle        = lipgloss.NewStyle().Background(lipgloss.Color("3")).Foreground(lipgloss.Color("0"))
+	currentHitStyle = lipgloss.NewStyle().Background(lipgloss.Color("211")).Foreground(lipgloss.Color("0"))
+)
+
+// HighlightSequence lays the sequence out like FormatSequence with the hits
+// highlighted, the current one in pink. Sequences longer than
+// FrameViewLimit only get the lines with hits on them. It returns, for each
+// hit, the output line its first base is on.
+func HighlightSequence(seq string, hits []MotifHit, current int) (string, []int) {
+	marks := make([]byte, len(seq))
+	for idx, h := range hits {
+		mark := byte(1)
+		if idx == current {
+			mark = 2
+		}
+		for i := h.Start - 1; i < h.End; i++ {
+			marks[i] = max(marks[i], mark)
+		}
+	}
+
+	var sb strings.Builder
+	lineOf := map[int]int{} // sequence line -> output line
+	lines, skipped := 0, false
+	for i := 0; i < len(seq); i += 60 {
+		end := min(i+60, len(seq))
+		if len(seq) > FrameViewLimit && !slices.ContainsFunc(marks[i:end], func(m byte) bool { return m > 0 }) {
+			skipped = true
+			continue
+		}
+		if skipped {
+			sb.WriteString(faintStyle.Render(fmt.Sprintf("%9s", "...")) + "\n")
+			lines++
+			skipped = false
+		}
+		lineOf[i/60] = lines
+		sb.WriteString(fmt.Sprintf("%9d", i+1))
+		for j := i; j < end; j += 10 {
+			sb.WriteString(" ")
+			// style runs of bases with the same mark together
+			for k := j; k < min(j+10, end); {
+				r := k
+				for r < min(j+10, end) && marks[r] == marks[k] {
+					r++
+				}
+				switch marks[k] {
+				case 1:
+					sb.WriteString(hitStyle.Render(seq[k:r]))
+				case 2:
+					sb.WriteString(currentHitStyle.Render(seq[k:r]))
+				default:
+					sb.WriteString(seq[k:r])
+				}
+				k = r
+			}
+		}
+		sb.WriteString("\n")
+		lines++
+	}
+
+	hitLines := make([]int, len(hits))
+	for idx, h := range hits {
+		hitLines[idx] = lineOf[(h.Start-1)/60]
+	}
+	return sb.String(), hitLines
+}
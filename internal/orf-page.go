@@ -0,0 +1,210 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// minimum ORF lengths to step through, in nucleotides
+var orfMinLengths = []int{75, 150, 300, 600, 30}
+
+type orfItem struct {
+	n   int
+	orf ORF
+}
+
+func (i orfItem) Title() string {
+	return fmt.Sprintf("ORF%d  %s", i.n, i.orf.Location())
+}
+
+func (i orfItem) Description() string {
+	d := fmt.Sprintf("frame %+d (%s), %d nt, %d aa", i.orf.Frame, i.orf.Strand(), i.orf.Length, len(i.orf.Protein))
+	if i.orf.Wraps() {
+		d += " - wraps the origin"
+	}
+	return d
+}
+
+func (i orfItem) FilterValue() string { return fmt.Sprintf("ORF%d %s", i.n, i.orf.Location()) }
+
+type orfPage struct {
+	Title    string
+	Name     string // accession.version, for the FASTA headers
+	Sequence string
+	Options  ORFOptions
+	MinIdx   int // index into orfMinLengths
+	ORFs     []ORF
+	Results  list.Model
+	Protein  *viewport.Model // the ORF opened with enter
+	Open     int
+	Status   string
+}
+
+func NewORFPage(seq GBSeq, gc GeneticCode, width, height int) *orfPage {
+	d := list.NewDefaultDelegate()
+	d.UpdateFunc = UpdateDelegate
+
+	page := &orfPage{
+		Title:    "ORFs in " + seq.PrimaryAccession,
+		Name:     CartItemFromSeq("nuccore", seq).Accession,
+		Sequence: seq.Sequence,
+		Options: ORFOptions{
+			MinLength: orfMinLengths[0],
+			Code:      gc,
+			Circular:  strings.EqualFold(seq.Topology, "circular"),
+		},
+		Results: list.New(nil, d, width, height-6),
+	}
+	page.search()
+	return page
+}
+
+// search reruns the finder with the current options.
+func (page *orfPage) search() {
+	page.ORFs = FindORFs(page.Sequence, page.Options)
+	items := make([]list.Item, len(page.ORFs))
+	for idx, o := range page.ORFs {
+		items[idx] = orfItem{n: idx + 1, orf: o}
+	}
+	page.Results.SetItems(items)
+	page.Results.ResetSelected()
+	page.Results.Title = fmt.Sprintf("%d ORFs of at least %d nt", len(page.ORFs), page.Options.MinLength)
+}
+
+// ORFReport describes one ORF and its translation.
+func ORFReport(name string, n int, o ORF) string {
+	var sb strings.Builder
+	padding := fmt.Sprintf("%%-%ds", LabelPadding)
+
+	sb.WriteString(fmt.Sprintf("=== ORF%d ===\n", n))
+	sb.WriteString(fmt.Sprintf(padding+" %s:%s\n", "Location:", name, o.Location()))
+	sb.WriteString(fmt.Sprintf(padding+" %+d (%s strand)\n", "Frame:", o.Frame, o.Strand()))
+	sb.WriteString(fmt.Sprintf(padding+" %d nt, %d aa\n", "Length:", o.Length, len(o.Protein)))
+	if o.Wraps() {
+		sb.WriteString(fmt.Sprintf(padding+" %s\n", "Topology:", "runs across the origin"))
+	}
+	stats := ComputeSeqStats(o.Protein, true)
+	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Mol. weight:", formatMass(stats.MW)))
+
+	sb.WriteString("\n--- TRANSLATION ---\n")
+	sb.WriteString(FormatSequence(o.Protein))
+	return sb.String()
+}
+
+// UpdatePage implements page.
+func (page *orfPage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if page.Protein != nil {
+			switch {
+			case key.Matches(msg, m.Keys.Back):
+				page.Protein = nil
+				return m, nil
+			case msg.String() == "x":
+				page.export(m, page.ORFs[page.Open-1:page.Open], fmt.Sprintf("%s_ORF%d", page.Name, page.Open))
+				return m, nil
+			}
+			var cmd tea.Cmd
+			*page.Protein, cmd = page.Protein.Update(msg)
+			return m, cmd
+		}
+
+		if page.Results.FilterState() == list.Filtering {
+			break
+		}
+		switch {
+		case msg.String() == "m":
+			page.MinIdx = (page.MinIdx + 1) % len(orfMinLengths)
+			page.Options.MinLength = orfMinLengths[page.MinIdx]
+			page.search()
+			return m, nil
+		case msg.String() == "s":
+			page.Options.Starts = (page.Options.Starts + 1) % (StartAny + 1)
+			page.search()
+			return m, nil
+		case msg.String() == "g":
+			for idx, gc := range GeneticCodes {
+				if gc.ID == page.Options.Code.ID {
+					page.Options.Code = GeneticCodes[(idx+1)%len(GeneticCodes)]
+					break
+				}
+			}
+			page.search()
+			return m, nil
+		case msg.String() == "c":
+			page.Options.Circular = !page.Options.Circular
+			page.search()
+			return m, nil
+		case msg.String() == "x":
+			page.export(m, page.ORFs, page.Name+"_ORFs")
+			return m, nil
+		case key.Matches(msg, m.Keys.Back) && page.Results.FilterState() == list.Unfiltered:
+			m.UpdateBack(msg)
+			return m, nil
+		}
+
+	case listSelectMsg:
+		if item, ok := page.Results.SelectedItem().(orfItem); ok {
+			v := viewport.New(page.Results.Width(), page.Results.Height()+4)
+			v.SetContent(ORFReport(page.Name, item.n, item.orf))
+			page.Protein = &v
+			page.Open = item.n
+		}
+		return m, nil
+
+	case tea.WindowSizeMsg:
+		page.Results.SetSize(msg.Width-20, msg.Height-14)
+	}
+
+	var cmd tea.Cmd
+	page.Results, cmd = page.Results.Update(msg)
+	return m, cmd
+}
+
+// export writes proteins as FASTA to the download directory, numbered as
+// they are in the list.
+func (page *orfPage) export(m Model, orfs []ORF, name string) {
+	if len(orfs) == 0 {
+		page.Status = "no ORFs to export"
+		return
+	}
+	recs := ORFFasta(page.Name, orfs)
+	if len(orfs) == 1 {
+		recs[0].ID = name
+	}
+	path, err := exportFasta(recs, m.Downloads.Dir, name+".faa", m.Downloads.Overwrite)
+	if err != nil {
+		page.Status = "export failed: " + err.Error()
+	} else {
+		page.Status = fmt.Sprintf("exported %d proteins to %s", len(recs), path)
+	}
+}
+
+// Page implements page.
+func (page *orfPage) Page(m Model) string {
+	topology := "linear"
+	if page.Options.Circular {
+		topology = "circular"
+	}
+	p := fmt.Sprintf("Genetic code %s, %s, %s sequence\n", page.Options.Code, page.Options.Starts, topology)
+	if page.Protein != nil {
+		p += faintStyle.Render("x: export this protein as FASTA • bksp: back to the list") + "\n\n"
+		p += page.Protein.View()
+	} else {
+		p += faintStyle.Render("enter: view translation • m: minimum length • s: start codons • g: genetic code • c: circular/linear • x: export all as FASTA") + "\n\n"
+		p += page.Results.View()
+	}
+	if page.Status != "" {
+		p += "\n" + faintStyle.Render(page.Status)
+	}
+	return p + "\n\n"
+}
+
+func (page *orfPage) GetTitle() string {
+	return page.Title
+}
@@ -0,0 +1,198 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// StartMode picks which codons may open a reading frame.
+type StartMode int
+
+const (
+	StartATG         StartMode = iota // ATG only
+	StartAlternative                  // every initiation codon in the table
+	StartAny                          // any sense codon, stop to stop
+)
+
+func (s StartMode) String() string {
+	switch s {
+	case StartAlternative:
+		return "ATG and alternative starts"
+	case StartAny:
+		return "any sense codon (stop to stop)"
+	}
+	return "ATG only"
+}
+
+type ORFOptions struct {
+	MinLength int // in nucleotides, stop codon included
+	Starts    StartMode
+	Code      GeneticCode
+	Circular  bool // let ORFs run across the origin
+}
+
+// ORF is one open reading frame. Intervals are in transcript order, with
+// From > To on the reverse strand, and there are two of them when the ORF
+// wraps around the origin of a circular sequence.
+type ORF struct {
+	Frame     int // 1, 2, 3 or -1, -2, -3, counted from each strand's first base
+	Intervals []GBInterval
+	Length    int // nucleotides, stop codon included
+	Protein   string
+}
+
+func (o ORF) Strand() string {
+	if o.Frame < 0 {
+		return "-"
+	}
+	return "+"
+}
+
+func (o ORF) Wraps() bool { return len(o.Intervals) > 1 }
+
+func (o ORF) Location() string { return FormatLocation(o.Intervals) }
+
+// left is the ORF's leftmost base on the forward strand or, when it wraps,
+// where it starts before the origin.
+func (o ORF) left() int {
+	left := 0
+	for _, iv := range o.Intervals {
+		left = max(left, min(iv.From, iv.To))
+	}
+	return left
+}
+
+// FindORFs searches all six frames. Each stop codon ends at most one ORF,
+// opened by the first start codon after the previous stop in the same
+// frame, so nested starts aren't reported separately. On a linear sequence
+// an ORF needs its stop codon; with StartAny the sequence ends count as
+// stops on the opening side.
+func FindORFs(seq string, opts ORFOptions) []ORF {
+	seq = strings.ReplaceAll(strings.ToUpper(seq), "U", "T")
+	if opts.Code.ID == 0 {
+		opts.Code = GeneticCodes[0]
+	}
+	orfs := append(strandORFs(seq, 1, opts), strandORFs(ReverseComplement(seq), -1, opts)...)
+	slices.SortStableFunc(orfs, func(a, b ORF) int { return a.left() - b.left() })
+	return orfs
+}
+
+// codonTests classifies codons for the scan, with a table lookup for the
+// plain ACGT codons and the genetic code's own methods for the rest.
+func codonTests(gc GeneticCode, mode StartMode) (isStop, isStart func(string) bool) {
+	var stops, starts [64]bool
+	for i := 0; i < 64; i++ {
+		stops[i] = gc.AAs[i] == '*'
+		starts[i] = gc.Starts[i] == 'M'
+	}
+	index := func(c string) int {
+		i := 0
+		for j := 0; j < 3; j++ {
+			b, ok := baseIndex[c[j]]
+			if !ok {
+				return -1
+			}
+			i = i*4 + b
+		}
+		return i
+	}
+	isStop = func(c string) bool {
+		if i := index(c); i >= 0 {
+			return stops[i]
+		}
+		return gc.IsStop(c)
+	}
+	isStart = func(c string) bool {
+		switch mode {
+		case StartAny:
+			return true
+		case StartATG:
+			return c == "ATG"
+		}
+		if i := index(c); i >= 0 {
+			return starts[i]
+		}
+		return gc.IsStart(c)
+	}
+	return isStop, isStart
+}
+
+func strandORFs(s string, strand int, opts ORFOptions) []ORF {
+	n := len(s)
+	isStop, isStart := codonTests(opts.Code, opts.Starts)
+
+	// a circular sequence is scanned three times over, keeping ORFs that
+	// start in the middle copy: the first gives every start its upstream
+	// context, the last lets ORFs run past the end
+	scan, lo, hi := s, 0, n
+	if opts.Circular {
+		scan, lo, hi = s+s+s, n, 2*n
+	}
+
+	var orfs []ORF
+	for f := 0; f < 3; f++ {
+		open := -1
+		if opts.Starts == StartAny && !opts.Circular {
+			open = f
+		}
+		for p := f; p+3 <= len(scan); p += 3 {
+			if p >= hi && (open < 0 || open >= hi) {
+				break
+			}
+			codon := scan[p : p+3]
+			if !isStop(codon) {
+				if open < 0 && isStart(codon) {
+					open = p
+				}
+				continue
+			}
+			end := p + 3
+			if open >= lo && open < hi && end-open > 3 && end-open >= opts.MinLength && end-open <= n {
+				orfs = append(orfs, newORF(scan[open:end], open-lo, end-lo, n, strand, opts))
+			}
+			open = -1
+			if opts.Starts == StartAny {
+				open = end
+			}
+		}
+	}
+	return orfs
+}
+
+// newORF places an ORF found at [a, b) on a strand of length n, where b may
+// run past n on a circular sequence.
+func newORF(nt string, a, b, n, strand int, opts ORFOptions) ORF {
+	var ivs []GBInterval
+	switch {
+	case strand > 0 && b <= n:
+		ivs = []GBInterval{{From: a + 1, To: b}}
+	case strand > 0:
+		ivs = []GBInterval{{From: a + 1, To: n}, {From: 1, To: b - n}}
+	case b <= n:
+		ivs = []GBInterval{{From: n - a, To: n - b + 1}}
+	default:
+		ivs = []GBInterval{{From: n - a, To: 1}, {From: n, To: 2*n - b + 1}}
+	}
+	protein := opts.Code.Translate(nt, TranslateOptions{CDS: opts.Starts != StartAny})
+	return ORF{
+		Frame:     strand * (a%3 + 1),
+		Intervals: ivs,
+		Length:    b - a,
+		Protein:   strings.TrimSuffix(protein, "*"),
+	}
+}
+
+// ORFFasta names the ORFs after the record, with the location, frame and
+// length in the description.
+func ORFFasta(name string, orfs []ORF) []FastaRecord {
+	recs := make([]FastaRecord, len(orfs))
+	for idx, o := range orfs {
+		recs[idx] = FastaRecord{
+			ID:          fmt.Sprintf("%s_ORF%d", name, idx+1),
+			Description: fmt.Sprintf("%s:%s frame %+d, %d nt, %d aa", name, o.Location(), o.Frame, o.Length, len(o.Protein)),
+			Sequence:    o.Protein,
+		}
+	}
+	return recs
+}
@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// product sizes and Tm windows (min, optimum, max) to step through
+var (
+	primerProducts = [][2]int{{100, 300}, {200, 500}, {400, 800}, {700, 1500}, {1000, 3000}}
+	primerTms      = [][3]float64{{57, 60, 63}, {52, 55, 58}, {62, 65, 68}}
+)
+
+type primerItem struct {
+	n    int
+	pair PrimerPair
+}
+
+func (i primerItem) Title() string {
+	return fmt.Sprintf("Pair %d  %d-%d, %d bp", i.n, i.pair.Forward.Start, i.pair.Reverse.End, i.pair.Product)
+}
+
+func (i primerItem) Description() string {
+	f, r := i.pair.Forward, i.pair.Reverse
+	return fmt.Sprintf("F %s (%.1f °C) • R %s (%.1f °C) • penalty %.1f", f.Seq, f.Tm, r.Seq, r.Tm, i.pair.Penalty)
+}
+
+func (i primerItem) FilterValue() string { return i.pair.Forward.Seq + " " + i.pair.Reverse.Seq }
+
+type primerPage struct {
+	Title    string
+	Name     string // accession.version, for the FASTA headers
+	Sequence string
+	Target   Selection
+	Options  PrimerOptions
+	Product  int // index into primerProducts
+	TmIdx    int // index into primerTms
+	Pairs    []PrimerPair
+	Results  list.Model
+	Pair     *viewport.Model // the pair opened with enter
+	Open     int
+	Status   string
+}
+
+func NewPrimerPage(seq GBSeq, target Selection, width, height int) *primerPage {
+	d := list.NewDefaultDelegate()
+	d.UpdateFunc = UpdateDelegate
+
+	page := &primerPage{
+		Title:    "Primers for " + seq.PrimaryAccession,
+		Name:     CartItemFromSeq("nuccore", seq).Accession,
+		Sequence: seq.Sequence,
+		Target:   target,
+		Options:  DefaultPrimerOptions,
+		Results:  list.New(nil, d, width, height-6),
+	}
+	// start with the smallest products the target fits in
+	for page.Product < len(primerProducts)-1 && target.Len()+2*page.Options.MinLength > primerProducts[page.Product][1] {
+		page.Product++
+	}
+	page.pick()
+	return page
+}
+
+// pick reruns the picker with the current options.
+func (page *primerPage) pick() {
+	page.Options.MinProduct, page.Options.MaxProduct = primerProducts[page.Product][0], primerProducts[page.Product][1]
+	tm := primerTms[page.TmIdx]
+	page.Options.MinTm, page.Options.OptTm, page.Options.MaxTm = tm[0], tm[1], tm[2]
+
+	pairs, err := PickPrimers(page.Sequence, page.Target, page.Options, DefaultConditions)
+	page.Pairs = pairs
+	page.Status = ""
+	switch {
+	case err != nil:
+		page.Status = err.Error()
+	case len(pairs) == 0:
+		page.Status = "no pairs meet the constraints, try other product sizes or Tms"
+	}
+	items := make([]list.Item, len(pairs))
+	for idx, pp := range pairs {
+		items[idx] = primerItem{n: idx + 1, pair: pp}
+	}
+	page.Results.SetItems(items)
+	page.Results.ResetSelected()
+	page.Results.Title = fmt.Sprintf("%d primer pairs around %d-%d", len(pairs), page.Target.Start, page.Target.End)
+}
+
+// UpdatePage implements page.
+func (page *primerPage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if page.Pair != nil {
+			switch {
+			case key.Matches(msg, m.Keys.Back):
+				page.Pair = nil
+				return m, nil
+			case msg.String() == "x":
+				page.export(m, page.Open)
+				return m, nil
+			}
+			var cmd tea.Cmd
+			*page.Pair, cmd = page.Pair.Update(msg)
+			return m, cmd
+		}
+
+		if page.Results.FilterState() == list.Filtering {
+			break
+		}
+		switch {
+		case msg.String() == "s":
+			page.Product = (page.Product + 1) % len(primerProducts)
+			page.pick()
+			return m, nil
+		case msg.String() == "t":
+			page.TmIdx = (page.TmIdx + 1) % len(primerTms)
+			page.pick()
+			return m, nil
+		case msg.String() == "x":
+			if item, ok := page.Results.SelectedItem().(primerItem); ok {
+				page.export(m, item.n)
+			}
+			return m, nil
+		case key.Matches(msg, m.Keys.Back) && page.Results.FilterState() == list.Unfiltered:
+			m.UpdateBack(msg)
+			return m, nil
+		}
+
+	case listSelectMsg:
+		if item, ok := page.Results.SelectedItem().(primerItem); ok {
+			v := viewport.New(page.Results.Width(), page.Results.Height()+4)
+			v.SetContent(PrimerPairReport(page.Name, item.n, item.pair, DefaultConditions))
+			page.Pair = &v
+			page.Open = item.n
+		}
+		return m, nil
+
+	case tea.WindowSizeMsg:
+		page.Results.SetSize(msg.Width-20, msg.Height-14)
+	}
+
+	var cmd tea.Cmd
+	page.Results, cmd = page.Results.Update(msg)
+	return m, cmd
+}
+
+// export writes a pair as FASTA to the download directory.
+func (page *primerPage) export(m Model, n int) {
+	pp := page.Pairs[n-1]
+	name := fmt.Sprintf("%s_pair%d", page.Name, n)
+	recs := []FastaRecord{
+		{ID: name + "_F", Description: fmt.Sprintf("%s:%d-%d Tm %.1f", page.Name, pp.Forward.Start, pp.Forward.End, pp.Forward.Tm), Sequence: pp.Forward.Seq},
+		{ID: name + "_R", Description: fmt.Sprintf("%s:c%d-%d Tm %.1f", page.Name, pp.Reverse.End, pp.Reverse.Start, pp.Reverse.Tm), Sequence: pp.Reverse.Seq},
+	}
+	path, err := exportFasta(recs, m.Downloads.Dir, name+".fa", m.Downloads.Overwrite)
+	if err != nil {
+		page.Status = "export failed: " + err.Error()
+	} else {
+		page.Status = "exported pair " + fmt.Sprint(n) + " to " + path
+	}
+}
+
+// Page implements page.
+func (page *primerPage) Page(m Model) string {
+	o := page.Options
+	p := fmt.Sprintf("Products %d-%d bp, Tm %g-%g °C (optimum %g), %d-%d nt, GC %g-%g%%\n",
+		o.MinProduct, o.MaxProduct, o.MinTm, o.MaxTm, o.OptTm, o.MinLength, o.MaxLength, o.MinGC, o.MaxGC)
+	if page.Pair != nil {
+		p += faintStyle.Render("x: export this pair as FASTA • bksp: back to the list") + "\n\n"
+		p += page.Pair.View()
+	} else {
+		p += faintStyle.Render("enter: view pair • s: product size • t: Tm range • x: export pair as FASTA") + "\n\n"
+		p += page.Results.View()
+	}
+	if page.Status != "" {
+		p += "\n" + faintStyle.Render(page.Status)
+	}
+	return p + "\n\n"
+}
+
+func (page *primerPage) GetTitle() string {
+	return page.Title
+}
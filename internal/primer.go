@@ -0,0 +1,450 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// PrimerMaxLength is the longest selection analyzed as a primer.
+const PrimerMaxLength = 60
+
+// PrimerConditions are the reaction conditions Tm depends on.
+type PrimerConditions struct {
+	Na, Mg, DNTP float64 // mM
+	Oligo        float64 // nM, of each strand
+}
+
+// DefaultConditions are Primer3's defaults.
+var DefaultConditions = PrimerConditions{Na: 50, Mg: 1.5, DNTP: 0.6, Oligo: 50}
+
+func (c PrimerConditions) String() string {
+	return fmt.Sprintf("%g mM Na+, %g mM Mg2+, %g mM dNTPs, %g nM oligo", c.Na, c.Mg, c.DNTP, c.Oligo)
+}
+
+// saltEntropy is the entropy correction per phosphate, in cal/K/mol, with
+// Mg2+ not bound up by dNTPs counted as monovalent the way von Ahsen et al.
+// (2001) do.
+func (c PrimerConditions) saltEntropy() float64 {
+	na := c.Na + 120*math.Sqrt(max(0, c.Mg-c.DNTP))
+	return 0.368 * math.Log(na/1000)
+}
+
+// SantaLucia (1998) unified nearest-neighbor parameters, 5'-XY-3' on the
+// top strand: enthalpy in kcal/mol, entropy in cal/K/mol
+var nnParams = map[string][2]float64{
+	"AA": {-7.9, -22.2}, "TT": {-7.9, -22.2},
+	"AT": {-7.2, -20.4},
+	"TA": {-7.2, -21.3},
+	"CA": {-8.5, -22.7}, "TG": {-8.5, -22.7},
+	"GT": {-8.4, -22.4}, "AC": {-8.4, -22.4},
+	"CT": {-7.8, -21.0}, "AG": {-7.8, -21.0},
+	"GA": {-8.2, -22.2}, "TC": {-8.2, -22.2},
+	"CG": {-10.6, -27.2},
+	"GC": {-9.8, -24.4},
+	"GG": {-8.0, -19.9}, "CC": {-8.0, -19.9},
+}
+
+const gasConstant = 1.987 // cal/K/mol
+
+// stacks sums the nearest-neighbor terms of seq paired with its complement.
+func stacks(seq string) (dh, ds float64) {
+	for i := 0; i+1 < len(seq); i++ {
+		p := nnParams[seq[i:i+2]]
+		dh, ds = dh+p[0], ds+p[1]
+	}
+	return dh, ds
+}
+
+// initiation adds the terms for each end of a duplex, which depend on
+// whether it ends in a G·C or an A·T pair.
+func initiation(seq string) (dh, ds float64) {
+	for _, b := range []byte{seq[0], seq[len(seq)-1]} {
+		if b == 'G' || b == 'C' {
+			dh, ds = dh+0.1, ds-2.8
+		} else {
+			dh, ds = dh+2.3, ds+4.1
+		}
+	}
+	return dh, ds
+}
+
+// duplexDG is the free energy at 37 °C of seq paired with its complement.
+func duplexDG(seq string, c PrimerConditions) float64 {
+	dh, ds := stacks(seq)
+	ih, is := initiation(seq)
+	ds += is + c.saltEntropy()*float64(len(seq)-1)
+	return dh + ih - 310.15*ds/1000
+}
+
+func checkPrimer(seq string) error {
+	if len(seq) < 2 {
+		return fmt.Errorf("a primer needs at least 2 bases")
+	}
+	if i := strings.IndexFunc(seq, func(r rune) bool { return !strings.ContainsRune("ACGT", r) }); i >= 0 {
+		return fmt.Errorf("can't work out the Tm with %q at base %d", seq[i], i+1)
+	}
+	return nil
+}
+
+// MeltingTemp is the nearest-neighbor Tm of an oligo with its perfect
+// complement, in °C. seq has to be uppercase ACGT.
+func MeltingTemp(seq string, c PrimerConditions) float64 {
+	dh, ds := stacks(seq)
+	ih, is := initiation(seq)
+	dh, ds = dh+ih, ds+is+c.saltEntropy()*float64(len(seq)-1)
+	ct := c.Oligo * 1e-9 / 4
+	if seq == ReverseComplement(seq) {
+		// self-complementary: one strand, and a symmetry term
+		ds -= 1.4
+		ct = c.Oligo * 1e-9
+	}
+	return dh*1000/(ds+gasConstant*math.Log(ct)) - 273.15
+}
+
+// Duplex is the most stable run of base pairs between two oligos lying
+// antiparallel. Offset places B (reversed) against A: A[i] pairs with the
+// base of B that ends up at i+Offset.
+type Duplex struct {
+	A, B     string
+	Offset   int
+	Start    int     // 0-based, in A
+	Length   int     // base pairs
+	DG       float64 // kcal/mol at 37 °C
+	ThreeEnd bool    // the run includes a 3' end, which polymerase can extend
+}
+
+// FindDuplex slides b along a and scores each run of Watson-Crick pairs.
+// Runs of one pair aren't counted.
+func FindDuplex(a, b string, c PrimerConditions) Duplex {
+	rb := []byte(b)
+	reverseBytes(rb)
+	best := Duplex{A: a, B: b}
+	for off := -len(a) + 1; off < len(b); off++ {
+		from, to := max(0, -off), min(len(a), len(b)-off)
+		for i := from; i < to; {
+			k := 0
+			for i+k < to && complements[a[i+k]] == rb[i+k+off] {
+				k++
+			}
+			if k >= 2 {
+				dg := duplexDG(a[i:i+k], c)
+				if dg < best.DG {
+					best.Offset, best.Start, best.Length, best.DG = off, i, k, dg
+					// B's 3' end is the first base of rb
+					best.ThreeEnd = i+k == len(a) || i+off == 0
+				}
+			}
+			i += max(1, k)
+		}
+	}
+	return best
+}
+
+// View draws the duplex with the paired run marked.
+func (d Duplex) View() string {
+	if d.Length == 0 {
+		return ""
+	}
+	rb := []byte(d.B)
+	reverseBytes(rb)
+	padA, padB := max(0, d.Offset), max(0, -d.Offset)
+	bars := strings.Repeat(" ", padA+d.Start) + strings.Repeat("|", d.Length)
+	return fmt.Sprintf("5' %s%s 3'\n   %s\n3' %s%s 5'\n",
+		strings.Repeat(" ", padA), d.A, bars, strings.Repeat(" ", padB), rb)
+}
+
+// Hairpin is the most stable stem-loop an oligo can fold into.
+type Hairpin struct {
+	Start, End int // 1-based, the outer pair of the stem
+	Stem, Loop int
+	DG         float64 // kcal/mol at 37 °C
+}
+
+// hairpin loop initiation (SantaLucia & Hicks, 2004), by loop length
+var hairpinLoops = map[int]float64{3: 3.5, 4: 3.5, 5: 3.3, 6: 4.0, 7: 4.2, 8: 4.3, 9: 4.5, 10: 4.6}
+
+func hairpinLoopDG(n int) float64 {
+	if dg, ok := hairpinLoops[n]; ok {
+		return dg
+	}
+	// longer loops extrapolate logarithmically
+	return hairpinLoops[10] + 1.75*gasConstant*310.15/1000*math.Log(float64(n)/10)
+}
+
+// FindHairpin looks for stems of at least 3 pairs closing a loop of at
+// least 3 bases. A zero Hairpin means none is stable.
+func FindHairpin(seq string, c PrimerConditions) Hairpin {
+	var best Hairpin
+	n := len(seq)
+	for i := 0; i < n; i++ {
+		for j := n - 1; j-i >= 8; j-- {
+			// start from the outermost pair of a stem only
+			if i > 0 && j < n-1 && complements[seq[i-1]] == seq[j+1] {
+				continue
+			}
+			k := 0
+			for j-i-2*k-1 >= 3 && complements[seq[i+k]] == seq[j-k] {
+				k++
+			}
+			if k < 3 {
+				continue
+			}
+			dh, ds := stacks(seq[i : i+k])
+			ds += c.saltEntropy() * float64(k-1)
+			loop := j - i - 2*k + 1
+			if dg := dh - 310.15*ds/1000 + hairpinLoopDG(loop); dg < best.DG {
+				best = Hairpin{Start: i + 1, End: j + 1, Stem: k, Loop: loop, DG: dg}
+			}
+		}
+	}
+	return best
+}
+
+// Primer is an oligo and what it's likely to do.
+type Primer struct {
+	Seq       string
+	Start     int // 1-based, on the top strand, for primers from the picker
+	End       int
+	Reverse   bool
+	Tm        float64
+	GC        float64 // percent
+	Clamp     int     // G or C among the last five bases at the 3' end
+	Hairpin   Hairpin
+	SelfDimer Duplex
+	Penalty   float64
+}
+
+// AnalyzePrimer works out the Tm, GC content and secondary structure of an
+// oligo, given 5' to 3'.
+func AnalyzePrimer(seq string, c PrimerConditions) (Primer, error) {
+	seq = strings.ReplaceAll(strings.ToUpper(seq), "U", "T")
+	if err := checkPrimer(seq); err != nil {
+		return Primer{}, err
+	}
+	if len(seq) > PrimerMaxLength {
+		return Primer{}, fmt.Errorf("%d nt is too long for a primer, select %d nt or less", len(seq), PrimerMaxLength)
+	}
+	p := primerBasics(seq, c)
+	p.Hairpin = FindHairpin(seq, c)
+	p.SelfDimer = FindDuplex(seq, seq, c)
+	return p, nil
+}
+
+// primerBasics is the cheap part of the analysis.
+func primerBasics(seq string, c PrimerConditions) Primer {
+	gc := strings.Count(seq, "G") + strings.Count(seq, "C")
+	end := seq[max(0, len(seq)-5):]
+	return Primer{
+		Seq:   seq,
+		Tm:    MeltingTemp(seq, c),
+		GC:    100 * float64(gc) / float64(len(seq)),
+		Clamp: strings.Count(end, "G") + strings.Count(end, "C"),
+	}
+}
+
+// ClampNote says whether the 3' end binds well without being sticky.
+func (p Primer) ClampNote() string {
+	switch {
+	case p.Clamp == 0:
+		return "no G or C in the last 5 bases, the 3' end may breathe"
+	case p.Clamp > 3:
+		return "more than 3 G or C in the last 5 bases, may prime nonspecifically"
+	case !strings.ContainsAny(p.Seq[len(p.Seq)-1:], "GC"):
+		return "ok, though it doesn't end in G or C"
+	}
+	return "ok"
+}
+
+// PrimerReport describes a single oligo.
+func PrimerReport(name string, p Primer, c PrimerConditions) string {
+	var sb strings.Builder
+	padding := fmt.Sprintf("%%-%ds", LabelPadding)
+
+	sb.WriteString("=== PRIMER ===\n")
+	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Name:", name))
+	sb.WriteString(fmt.Sprintf(padding+" 5'-%s-3'\n", "Sequence:", p.Seq))
+	sb.WriteString(fmt.Sprintf(padding+" %d nt\n", "Length:", len(p.Seq)))
+	sb.WriteString(fmt.Sprintf(padding+" %.1f °C\n", "Tm:", p.Tm))
+	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Conditions:", c))
+	sb.WriteString(fmt.Sprintf(padding+" %.1f%%\n", "GC content:", p.GC))
+	sb.WriteString(fmt.Sprintf(padding+" %d of 5, %s\n", "GC clamp:", p.Clamp, p.ClampNote()))
+
+	sb.WriteString("\n--- HAIRPIN ---\n")
+	if h := p.Hairpin; h.Stem > 0 {
+		sb.WriteString(fmt.Sprintf("%d bp stem (bases %d-%d with %d-%d), %d nt loop, ΔG %.1f kcal/mol\n",
+			h.Stem, h.Start, h.Start+h.Stem-1, h.End-h.Stem+1, h.End, h.Loop, h.DG))
+	} else {
+		sb.WriteString("none stable at 37 °C\n")
+	}
+
+	sb.WriteString("\n--- SELF-DIMER ---\n")
+	sb.WriteString(duplexLine(p.SelfDimer))
+	return sb.String()
+}
+
+func duplexLine(d Duplex) string {
+	if d.Length == 0 {
+		return "none\n"
+	}
+	s := fmt.Sprintf("%d bp, ΔG %.1f kcal/mol", d.Length, d.DG)
+	if d.ThreeEnd {
+		s += ", at a 3' end"
+	}
+	return s + "\n" + d.View()
+}
+
+// PrimerOptions constrain the primer pair picker.
+type PrimerOptions struct {
+	MinLength, MaxLength   int
+	MinTm, OptTm, MaxTm    float64
+	MaxTmDiff              float64
+	MinGC, MaxGC           float64
+	MinProduct, MaxProduct int
+}
+
+// DefaultPrimerOptions are the usual rules of thumb.
+var DefaultPrimerOptions = PrimerOptions{
+	MinLength: 18, MaxLength: 25,
+	MinTm: 57, OptTm: 60, MaxTm: 63,
+	MaxTmDiff: 3,
+	MinGC:     40, MaxGC: 60,
+	MinProduct: 100, MaxProduct: 300,
+}
+
+// PrimerPair is a forward and a reverse primer around a target.
+type PrimerPair struct {
+	Forward, Reverse Primer
+	Product          int
+	Dimer            Duplex
+	Penalty          float64
+}
+
+// how many pairs to suggest, and how many of the best primers on each side
+// to try pairing
+const (
+	PrimerPairLimit  = 20
+	primerCandidates = 50
+)
+
+// PickPrimers suggests primer pairs whose product covers the target, best
+// first. A pair's penalty adds up how far each primer's Tm is from the
+// optimum, the difference between them, weak or sticky 3' ends and any
+// stable hairpins or dimers.
+func PickPrimers(seq string, target Selection, opts PrimerOptions, c PrimerConditions) ([]PrimerPair, error) {
+	seq = strings.ReplaceAll(strings.ToUpper(seq), "U", "T")
+	if target.Len()+2*opts.MinLength > opts.MaxProduct {
+		return nil, fmt.Errorf("a %d bp target doesn't fit in a product of at most %d bp", target.Len(), opts.MaxProduct)
+	}
+
+	candidate := func(s string) (Primer, bool) {
+		if checkPrimer(s) != nil {
+			return Primer{}, false
+		}
+		p := primerBasics(s, c)
+		if p.Tm < opts.MinTm || p.Tm > opts.MaxTm || p.GC < opts.MinGC || p.GC > opts.MaxGC {
+			return p, false
+		}
+		p.Hairpin = FindHairpin(s, c)
+		p.SelfDimer = FindDuplex(s, s, c)
+		p.Penalty = math.Abs(p.Tm-opts.OptTm) + structurePenalty(p.Hairpin.DG, p.SelfDimer)
+		if p.Clamp == 0 || p.Clamp > 3 {
+			p.Penalty++
+		}
+		return p, true
+	}
+
+	// forward primers end before the target, reverse ones start after it,
+	// both within a product's length of it (0-based, end exclusive)
+	var fwd, rev []Primer
+	for l := opts.MinLength; l <= opts.MaxLength; l++ {
+		for s := max(0, target.End-opts.MaxProduct); s+l < target.Start; s++ {
+			if p, ok := candidate(seq[s : s+l]); ok {
+				p.Start, p.End = s+1, s+l
+				fwd = append(fwd, p)
+			}
+		}
+		for e := target.End + l; e <= min(len(seq), target.Start-1+opts.MaxProduct); e++ {
+			if p, ok := candidate(ReverseComplement(seq[e-l : e])); ok {
+				p.Start, p.End, p.Reverse = e-l+1, e, true
+				rev = append(rev, p)
+			}
+		}
+	}
+	byPenalty := func(a, b Primer) int { return cmpFloat(a.Penalty, b.Penalty) }
+	slices.SortFunc(fwd, byPenalty)
+	slices.SortFunc(rev, byPenalty)
+	fwd, rev = fwd[:min(len(fwd), primerCandidates)], rev[:min(len(rev), primerCandidates)]
+
+	var pairs []PrimerPair
+	for _, f := range fwd {
+		for _, r := range rev {
+			product := r.End - f.Start + 1
+			diff := math.Abs(f.Tm - r.Tm)
+			if product < opts.MinProduct || product > opts.MaxProduct || diff > opts.MaxTmDiff {
+				continue
+			}
+			dimer := FindDuplex(f.Seq, r.Seq, c)
+			pairs = append(pairs, PrimerPair{
+				Forward: f, Reverse: r, Product: product, Dimer: dimer,
+				Penalty: f.Penalty + r.Penalty + diff + structurePenalty(0, dimer),
+			})
+		}
+	}
+	slices.SortFunc(pairs, func(a, b PrimerPair) int { return cmpFloat(a.Penalty, b.Penalty) })
+	return pairs[:min(len(pairs), PrimerPairLimit)], nil
+}
+
+// structurePenalty charges for hairpins and dimers stable enough to matter,
+// dimers that polymerase can extend more so.
+func structurePenalty(hairpin float64, dimer Duplex) float64 {
+	penalty := max(0, -hairpin-1)
+	limit := -6.0
+	if dimer.ThreeEnd {
+		limit = -4
+	}
+	return penalty + max(0, limit-dimer.DG)
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// PrimerPairReport describes a pair from the picker.
+func PrimerPairReport(name string, n int, pp PrimerPair, c PrimerConditions) string {
+	var sb strings.Builder
+	padding := fmt.Sprintf("%%-%ds", LabelPadding)
+
+	sb.WriteString(fmt.Sprintf("=== PRIMER PAIR %d ===\n", n))
+	sb.WriteString(fmt.Sprintf(padding+" %s:%d-%d, %d bp\n", "Product:", name, pp.Forward.Start, pp.Reverse.End, pp.Product))
+	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Conditions:", c))
+	sb.WriteString(fmt.Sprintf(padding+" %.1f\n", "Penalty:", pp.Penalty))
+	for _, p := range []Primer{pp.Forward, pp.Reverse} {
+		label := "FORWARD"
+		if p.Reverse {
+			label = "REVERSE"
+		}
+		sb.WriteString(fmt.Sprintf("\n--- %s ---\n", label))
+		sb.WriteString(fmt.Sprintf(padding+" 5'-%s-3'\n", "Sequence:", p.Seq))
+		sb.WriteString(fmt.Sprintf(padding+" %d-%d, %d nt\n", "Position:", p.Start, p.End, len(p.Seq)))
+		sb.WriteString(fmt.Sprintf(padding+" %.1f °C\n", "Tm:", p.Tm))
+		sb.WriteString(fmt.Sprintf(padding+" %.1f%%\n", "GC content:", p.GC))
+		sb.WriteString(fmt.Sprintf(padding+" %d of 5, %s\n", "GC clamp:", p.Clamp, p.ClampNote()))
+		if h := p.Hairpin; h.Stem > 0 {
+			sb.WriteString(fmt.Sprintf(padding+" %d bp stem, ΔG %.1f kcal/mol\n", "Hairpin:", h.Stem, h.DG))
+		} else {
+			sb.WriteString(fmt.Sprintf(padding+" none\n", "Hairpin:"))
+		}
+		sb.WriteString(fmt.Sprintf(padding+" %s", "Self-dimer:", duplexLine(p.SelfDimer)))
+	}
+	sb.WriteString("\n--- PRIMER-DIMER ---\n")
+	sb.WriteString(duplexLine(pp.Dimer))
+	return sb.String()
+}
@@ -0,0 +1,244 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// hydropathy windows to step through, 19 being the usual one for spotting
+// transmembrane helices
+var hydropathyWindows = []int{19, 21, 15, 11, 9, 7, 5}
+
+// windows averaging at least this much are likely transmembrane, with a
+// 19 residue window (Kyte & Doolittle, 1982)
+const transmembraneHydropathy = 1.6
+
+// Kyte-Doolittle hydropathy
+var kyteDoolittle = map[byte]float64{
+	'A': 1.8, 'R': -4.5, 'N': -3.5, 'D': -3.5, 'C': 2.5, 'Q': -3.5, 'E': -3.5,
+	'G': -0.4, 'H': -3.2, 'I': 4.5, 'L': 3.8, 'K': -3.9, 'M': 1.9, 'F': 2.8,
+	'P': -1.6, 'S': -0.8, 'T': -0.7, 'W': -0.9, 'Y': -1.3, 'V': 4.2,
+}
+
+// pKa values of the charged groups (Bjellqvist et al., 1993), as ExPASy's
+// Compute pI/Mw uses them. The termini depend on the residue there.
+var (
+	positivePK = map[byte]float64{'K': 10.0, 'R': 12.0, 'H': 5.98}
+	negativePK = map[byte]float64{'D': 4.05, 'E': 4.45, 'C': 9.0, 'Y': 10.0}
+	nTermPKs   = map[byte]float64{'A': 7.59, 'M': 7.0, 'S': 6.93, 'P': 8.36, 'T': 6.82, 'V': 7.44, 'E': 7.7}
+	cTermPKs   = map[byte]float64{'D': 4.55, 'E': 4.75}
+)
+
+const nTermPK, cTermPK = 7.5, 3.55
+
+// dipeptide instability weights (Guruprasad et al., 1990), by first then
+// second residue
+var instabilityWeights = map[byte]map[byte]float64{
+	'A': {'A': 1.0, 'C': 44.94, 'E': 1.0, 'D': -7.49, 'G': 1.0, 'F': 1.0, 'I': 1.0, 'H': -7.49, 'K': 1.0, 'M': 1.0, 'L': 1.0, 'N': 1.0, 'Q': 1.0, 'P': 20.26, 'S': 1.0, 'R': 1.0, 'T': 1.0, 'W': 1.0, 'V': 1.0, 'Y': 1.0},
+	'C': {'A': 1.0, 'C': 1.0, 'E': 1.0, 'D': 20.26, 'G': 1.0, 'F': 1.0, 'I': 1.0, 'H': 33.60, 'K': 1.0, 'M': 33.60, 'L': 20.26, 'N': 1.0, 'Q': -6.54, 'P': 20.26, 'S': 1.0, 'R': 1.0, 'T': 33.60, 'W': 24.68, 'V': -6.54, 'Y': 1.0},
+	'E': {'A': 1.0, 'C': 44.94, 'E': 33.60, 'D': 20.26, 'G': 1.0, 'F': 1.0, 'I': 20.26, 'H': -6.54, 'K': 1.0, 'M': 1.0, 'L': 1.0, 'N': 1.0, 'Q': 20.26, 'P': 20.26, 'S': 20.26, 'R': 1.0, 'T': 1.0, 'W': -14.03, 'V': 1.0, 'Y': 1.0},
+	'D': {'A': 1.0, 'C': 1.0, 'E': 1.0, 'D': 1.0, 'G': 1.0, 'F': -6.54, 'I': 1.0, 'H': 1.0, 'K': -7.49, 'M': 1.0, 'L': 1.0, 'N': 1.0, 'Q': 1.0, 'P': 1.0, 'S': 20.26, 'R': -6.54, 'T': -14.03, 'W': 1.0, 'V': 1.0, 'Y': 1.0},
+	'G': {'A': -7.49, 'C': 1.0, 'E': -6.54, 'D': 1.0, 'G': 13.34, 'F': 1.0, 'I': -7.49, 'H': 1.0, 'K': -7.49, 'M': 1.0, 'L': 1.0, 'N': -7.49, 'Q': 1.0, 'P': 1.0, 'S': 1.0, 'R': 1.0, 'T': -7.49, 'W': 13.34, 'V': 1.0, 'Y': -7.49},
+	'F': {'A': 1.0, 'C': 1.0, 'E': 1.0, 'D': 13.34, 'G': 1.0, 'F': 1.0, 'I': 1.0, 'H': 1.0, 'K': -14.03, 'M': 1.0, 'L': 1.0, 'N': 1.0, 'Q': 1.0, 'P': 20.26, 'S': 1.0, 'R': 1.0, 'T': 1.0, 'W': 1.0, 'V': 1.0, 'Y': 33.601},
+	'I': {'A': 1.0, 'C': 1.0, 'E': 44.94, 'D': 1.0, 'G': 1.0, 'F': 1.0, 'I': 1.0, 'H': 13.34, 'K': -7.49, 'M': 1.0, 'L': 20.26, 'N': 1.0, 'Q': 1.0, 'P': -1.88, 'S': 1.0, 'R': 1.0, 'T': 1.0, 'W': 1.0, 'V': -7.49, 'Y': 1.0},
+	'H': {'A': 1.0, 'C': 1.0, 'E': 1.0, 'D': 1.0, 'G': -9.37, 'F': -9.37, 'I': 44.94, 'H': 1.0, 'K': 24.68, 'M': 1.0, 'L': 1.0, 'N': 24.68, 'Q': 1.0, 'P': -1.88, 'S': 1.0, 'R': 1.0, 'T': -6.54, 'W': -1.88, 'V': 1.0, 'Y': 44.94},
+	'K': {'A': 1.0, 'C': 1.0, 'E': 1.0, 'D': 1.0, 'G': -7.49, 'F': 1.0, 'I': -7.49, 'H': 1.0, 'K': 1.0, 'M': 33.60, 'L': -7.49, 'N': 1.0, 'Q': 24.64, 'P': -6.54, 'S': 1.0, 'R': 33.60, 'T': 1.0, 'W': 1.0, 'V': -7.49, 'Y': 1.0},
+	'M': {'A': 13.34, 'C': 1.0, 'E': 1.0, 'D': 1.0, 'G': 1.0, 'F': 1.0, 'I': 1.0, 'H': 58.28, 'K': 1.0, 'M': -1.88, 'L': 1.0, 'N': 1.0, 'Q': -6.54, 'P': 44.94, 'S': 44.94, 'R': -6.54, 'T': -1.88, 'W': 1.0, 'V': 1.0, 'Y': 24.68},
+	'L': {'A': 1.0, 'C': 1.0, 'E': 1.0, 'D': 1.0, 'G': 1.0, 'F': 1.0, 'I': 1.0, 'H': 1.0, 'K': -7.49, 'M': 1.0, 'L': 1.0, 'N': 1.0, 'Q': 33.60, 'P': 20.26, 'S': 1.0, 'R': 20.26, 'T': 1.0, 'W': 24.68, 'V': 1.0, 'Y': 1.0},
+	'N': {'A': 1.0, 'C': -1.88, 'E': 1.0, 'D': 1.0, 'G': -14.03, 'F': -14.03, 'I': 44.94, 'H': 1.0, 'K': 24.68, 'M': 1.0, 'L': 1.0, 'N': 1.0, 'Q': -6.54, 'P': -1.88, 'S': 1.0, 'R': 1.0, 'T': -7.49, 'W': -9.37, 'V': 1.0, 'Y': 1.0},
+	'Q': {'A': 1.0, 'C': -6.54, 'E': 20.26, 'D': 20.26, 'G': 1.0, 'F': -6.54, 'I': 1.0, 'H': 1.0, 'K': 1.0, 'M': 1.0, 'L': 1.0, 'N': 1.0, 'Q': 20.26, 'P': 20.26, 'S': 44.94, 'R': 1.0, 'T': 1.0, 'W': 1.0, 'V': -6.54, 'Y': -6.54},
+	'P': {'A': 20.26, 'C': -6.54, 'E': 18.38, 'D': -6.54, 'G': 1.0, 'F': 20.26, 'I': 1.0, 'H': 1.0, 'K': 1.0, 'M': -6.54, 'L': 1.0, 'N': 1.0, 'Q': 20.26, 'P': 20.26, 'S': 20.26, 'R': -6.54, 'T': 1.0, 'W': -1.88, 'V': 20.26, 'Y': 1.0},
+	'S': {'A': 1.0, 'C': 33.60, 'E': 20.26, 'D': 1.0, 'G': 1.0, 'F': 1.0, 'I': 1.0, 'H': 1.0, 'K': 1.0, 'M': 1.0, 'L': 1.0, 'N': 1.0, 'Q': 20.26, 'P': 44.94, 'S': 20.26, 'R': 20.26, 'T': 1.0, 'W': 1.0, 'V': 1.0, 'Y': 1.0},
+	'R': {'A': 1.0, 'C': 1.0, 'E': 1.0, 'D': 1.0, 'G': -7.49, 'F': 1.0, 'I': 1.0, 'H': 20.26, 'K': 1.0, 'M': 1.0, 'L': 1.0, 'N': 13.34, 'Q': 20.26, 'P': 20.26, 'S': 44.94, 'R': 58.28, 'T': 1.0, 'W': 58.28, 'V': 1.0, 'Y': -6.54},
+	'T': {'A': 1.0, 'C': 1.0, 'E': 20.26, 'D': 1.0, 'G': -7.49, 'F': 13.34, 'I': 1.0, 'H': 1.0, 'K': 1.0, 'M': 1.0, 'L': 1.0, 'N': -14.03, 'Q': -6.54, 'P': 1.0, 'S': 1.0, 'R': 1.0, 'T': 1.0, 'W': -14.03, 'V': 1.0, 'Y': 1.0},
+	'W': {'A': -14.03, 'C': 1.0, 'E': 1.0, 'D': 1.0, 'G': -9.37, 'F': 1.0, 'I': 1.0, 'H': 24.68, 'K': 1.0, 'M': 24.68, 'L': 13.34, 'N': 13.34, 'Q': 1.0, 'P': 1.0, 'S': 1.0, 'R': 1.0, 'T': -14.03, 'W': 1.0, 'V': -7.49, 'Y': 1.0},
+	'V': {'A': 1.0, 'C': 1.0, 'E': 1.0, 'D': -14.03, 'G': -7.49, 'F': 1.0, 'I': 1.0, 'H': 1.0, 'K': -1.88, 'M': 1.0, 'L': 1.0, 'N': 1.0, 'Q': 1.0, 'P': 20.26, 'S': 1.0, 'R': 1.0, 'T': -7.49, 'W': 1.0, 'V': 1.0, 'Y': -6.54},
+	'Y': {'A': 24.68, 'C': 1.0, 'E': -6.54, 'D': 24.68, 'G': -7.49, 'F': 1.0, 'I': 1.0, 'H': 13.34, 'K': 1.0, 'M': 44.94, 'L': 1.0, 'N': 1.0, 'Q': 1.0, 'P': 13.34, 'S': 1.0, 'R': -15.91, 'T': -7.49, 'W': -9.37, 'V': 1.0, 'Y': 13.34},
+}
+
+// ProteinProperties are the ProtParam-style properties of a protein.
+type ProteinProperties struct {
+	Length      int
+	MW          float64
+	PI          float64
+	Extinction  float64 // M⁻¹ cm⁻¹ at 280 nm, all cysteines as cystines
+	Reduced     float64 // the same with all cysteines reduced
+	Instability float64 // above 40 the protein is probably unstable
+	Aliphatic   float64
+	GRAVY       float64
+}
+
+// ComputeProteinProperties works out the properties from the composition.
+// Letters other than the 20 standard amino acids are left out.
+func ComputeProteinProperties(seq string) ProteinProperties {
+	seq = strings.ToUpper(strings.TrimRight(seq, "*"))
+	pp := ProteinProperties{Length: len(seq), MW: ComputeSeqStats(seq, true).MW}
+	if len(seq) == 0 {
+		return pp
+	}
+	counts := map[byte]int{}
+	for i := 0; i < len(seq); i++ {
+		counts[seq[i]]++
+	}
+	n := func(aa byte) float64 { return float64(counts[aa]) }
+
+	pp.PI = isoelectricPoint(counts, seq[0], seq[len(seq)-1])
+	pp.Reduced = 5500*n('W') + 1490*n('Y')
+	pp.Extinction = pp.Reduced + 125*float64(counts['C']/2)
+
+	var sum float64
+	for i := 0; i+1 < len(seq); i++ {
+		sum += instabilityWeights[seq[i]][seq[i+1]]
+	}
+	pp.Instability = 10 * sum / float64(len(seq))
+
+	// mole percents, so the index is relative to alanine
+	molePercent := func(aa byte) float64 { return 100 * n(aa) / float64(len(seq)) }
+	pp.Aliphatic = molePercent('A') + 2.9*molePercent('V') + 3.9*(molePercent('I')+molePercent('L'))
+
+	var hydropathy float64
+	var residues int
+	for aa, c := range counts {
+		if h, ok := kyteDoolittle[aa]; ok {
+			hydropathy += h * float64(c)
+			residues += c
+		}
+	}
+	if residues > 0 {
+		pp.GRAVY = hydropathy / float64(residues)
+	}
+	return pp
+}
+
+// isoelectricPoint finds the pH where the net charge is zero by bisection.
+func isoelectricPoint(counts map[byte]int, first, last byte) float64 {
+	nTerm, cTerm := nTermPK, cTermPK
+	if pk, ok := nTermPKs[first]; ok {
+		nTerm = pk
+	}
+	if pk, ok := cTermPKs[last]; ok {
+		cTerm = pk
+	}
+	charge := func(ph float64) float64 {
+		c := 1 / (1 + math.Pow(10, ph-nTerm))
+		c -= 1 / (1 + math.Pow(10, cTerm-ph))
+		for aa, pk := range positivePK {
+			c += float64(counts[aa]) / (1 + math.Pow(10, ph-pk))
+		}
+		for aa, pk := range negativePK {
+			c -= float64(counts[aa]) / (1 + math.Pow(10, pk-ph))
+		}
+		return c
+	}
+	lo, hi := 0.0, 14.0
+	for hi-lo > 0.0001 {
+		mid := (lo + hi) / 2
+		if charge(mid) > 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// Hydropathy averages Kyte-Doolittle values over a sliding window, one value
+// per window; windows are placed from the first residue, so value i covers
+// residues i+1 to i+window. Unknown residues count as 0.
+func Hydropathy(seq string, window int) []float64 {
+	seq = strings.ToUpper(seq)
+	if window <= 0 || len(seq) < window {
+		return nil
+	}
+	out := make([]float64, 0, len(seq)-window+1)
+	var sum float64
+	for i := 0; i < len(seq); i++ {
+		sum += kyteDoolittle[seq[i]]
+		if i >= window {
+			sum -= kyteDoolittle[seq[i-window]]
+		}
+		if i >= window-1 {
+			out = append(out, sum/float64(window))
+		}
+	}
+	return out
+}
+
+// hydrophobicSegments merges overlapping windows at or above the
+// transmembrane threshold into 1-based residue ranges.
+func hydrophobicSegments(values []float64, window int) [][2]int {
+	var segs [][2]int
+	for i, v := range values {
+		if v < transmembraneHydropathy {
+			continue
+		}
+		if n := len(segs); n > 0 && i+1 <= segs[n-1][1] {
+			segs[n-1][1] = i + window
+		} else {
+			segs = append(segs, [2]int{i + 1, i + window})
+		}
+	}
+	return segs
+}
+
+// ProteinReport renders the properties panel, with a hydropathy sparkline
+// width characters wide in which stretches hydrophobic enough to span a
+// membrane are red.
+func ProteinReport(seq string, window, width int) string {
+	var sb strings.Builder
+	padding := fmt.Sprintf("%%-%ds", LabelPadding)
+	pp := ComputeProteinProperties(seq)
+
+	sb.WriteString("--- PROTEIN PROPERTIES ---\n")
+	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Molecular weight:", formatMass(pp.MW)))
+	sb.WriteString(fmt.Sprintf(padding+" %.2f\n", "Theoretical pI:", pp.PI))
+	ext := fmt.Sprintf("%.0f M⁻¹cm⁻¹, %.0f with all Cys reduced", pp.Extinction, pp.Reduced)
+	if pp.MW > 0 && pp.Extinction > 0 {
+		ext += fmt.Sprintf(" (Abs 0.1%% = %.3f)", pp.Extinction/pp.MW)
+	}
+	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Ext. coeff. 280 nm:", ext))
+	stability := "stable"
+	if pp.Instability > 40 {
+		stability = "probably unstable"
+	}
+	sb.WriteString(fmt.Sprintf(padding+" %.2f (%s)\n", "Instability index:", pp.Instability, stability))
+	sb.WriteString(fmt.Sprintf(padding+" %.2f\n", "Aliphatic index:", pp.Aliphatic))
+	sb.WriteString(fmt.Sprintf(padding+" %.3f\n", "GRAVY:", pp.GRAVY))
+
+	values := Hydropathy(seq, window)
+	if len(values) == 0 {
+		return sb.String()
+	}
+	width = max(width-12, 10)
+	shown := Downsample(values, width)
+	var line strings.Builder
+	for _, v := range shown {
+		c := Sparkline([]float64{v}, -4.5, 4.5)
+		if v >= transmembraneHydropathy {
+			c = errorStyle.Render(c)
+		}
+		line.WriteString(c)
+	}
+	sb.WriteString(fmt.Sprintf("\nKyte-Doolittle hydropathy, window of %d:\n", window))
+	sb.WriteString(fmt.Sprintf("  %-9s %s\n", "KD ±4.5", line.String()))
+	if len(values) > len(shown) {
+		sb.WriteString(faintStyle.Render(fmt.Sprintf("  %-9s each mark averages about %d windows", "", len(values)/len(shown))) + "\n")
+	}
+
+	// the threshold only means something for 19 residue windows
+	if window != 19 {
+		return sb.String()
+	}
+	segs := hydrophobicSegments(values, window)
+	if len(segs) == 0 {
+		sb.WriteString(fmt.Sprintf(padding+" %s\n", "Transmembrane:", "no likely segments"))
+		return sb.String()
+	}
+	ranges := make([]string, len(segs))
+	for idx, s := range segs {
+		ranges[idx] = fmt.Sprintf("%d-%d", s[0], s[1])
+	}
+	sb.WriteString(fmt.Sprintf(padding+" %d likely, %s\n", "Transmembrane:", len(segs), strings.Join(ranges, ", ")))
+	return sb.String()
+}
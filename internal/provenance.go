@@ -0,0 +1,166 @@
+package internal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Version is the biodata version recorded in provenance sidecars. Release
+// builds set it with -ldflags "-X github.com/zongleon/biodata/internal.Version=...".
+var Version = "dev"
+
+// ProvenanceSuffix is appended to a download's path to name its sidecar.
+const ProvenanceSuffix = ".provenance.json"
+
+type ProvenanceRecord struct {
+	Accession  string `json:"accession"`
+	UpdateDate string `json:"update_date,omitempty"`
+}
+
+// Provenance describes exactly how a downloaded file was produced.
+type Provenance struct {
+	File        string             `json:"file"`
+	Database    string             `json:"database"`
+	Records     []ProvenanceRecord `json:"records"`
+	Method      string             `json:"method"`
+	URL         string             `json:"url"`
+	Params      url.Values         `json:"params"`
+	Regions     []string           `json:"regions,omitempty"` // per-interval queries of an extraction
+	Format      string             `json:"format"`
+	Compression string             `json:"compression"`
+	Retrieved   time.Time          `json:"retrieved"`
+	Tool        string             `json:"tool"`
+	ToolVersion string             `json:"tool_version"`
+	Size        int64              `json:"size"`
+	SHA256      string             `json:"sha256"`
+}
+
+func NewProvenance(job *DownloadJob, size int64, sum []byte) Provenance {
+	records := make([]ProvenanceRecord, len(job.Ids))
+	for idx, id := range job.Ids {
+		records[idx].Accession = id
+		if idx < len(job.UpdateDates) {
+			records[idx].UpdateDate = job.UpdateDates[idx]
+		}
+	}
+	method := http.MethodGet
+	if len(job.Ids) > 1 && len(job.Regions) == 0 {
+		method = http.MethodPost
+	}
+	var regions []string
+	for _, rec := range job.Regions {
+		regions = append(regions, RegionParams(job.Database, rec).Encode())
+	}
+	return Provenance{
+		File:        filepath.Base(job.Path),
+		Database:    job.Database,
+		Records:     records,
+		Method:      method,
+		URL:         job.URL(),
+		Params:      job.Params(),
+		Regions:     regions,
+		Format:      job.Format.Name,
+		Compression: job.Compress.String(),
+		Retrieved:   job.Started.UTC(),
+		Tool:        "biodata",
+		ToolVersion: Version,
+		Size:        size,
+		SHA256:      hex.EncodeToString(sum),
+	}
+}
+
+func WriteProvenance(path string, prov Provenance) error {
+	f, err := os.Create(path + ProvenanceSuffix)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false) // keep the URL readable
+	err = enc.Encode(prov)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func ReadProvenance(sidecar string) (Provenance, error) {
+	var prov Provenance
+	data, err := os.ReadFile(sidecar)
+	if err != nil {
+		return prov, err
+	}
+	if err := json.Unmarshal(data, &prov); err != nil {
+		return prov, fmt.Errorf("failed to parse %s: %v", sidecar, err)
+	}
+	return prov, nil
+}
+
+func FileSHA256(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+type VerifyResult struct {
+	Path string
+	OK   bool
+	Err  error
+}
+
+// Verify re-checks every download under dir against its provenance sidecar.
+func Verify(dir string) ([]VerifyResult, error) {
+	var results []VerifyResult
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ProvenanceSuffix) {
+			return nil
+		}
+		results = append(results, verifyOne(path))
+		return nil
+	})
+	return results, err
+}
+
+func verifyOne(sidecar string) VerifyResult {
+	file := strings.TrimSuffix(sidecar, ProvenanceSuffix)
+	res := VerifyResult{Path: file}
+
+	prov, err := ReadProvenance(sidecar)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	sum, size, err := FileSHA256(file)
+	switch {
+	case err != nil:
+		res.Err = err
+	case size != prov.Size:
+		res.Err = fmt.Errorf("size is %d bytes, manifest says %d", size, prov.Size)
+	case sum != prov.SHA256:
+		res.Err = fmt.Errorf("sha256 is %s, manifest says %s", sum, prov.SHA256)
+	default:
+		res.OK = true
+	}
+	return res
+}
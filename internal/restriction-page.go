@@ -0,0 +1,246 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// which enzymes the restriction page lists
+var enzymeFilters = []string{"single cutters", "double cutters", "all cutters", "non-cutters", "all enzymes"}
+
+type enzymeItem struct {
+	ec     EnzymeCuts
+	cuts   []Cut // those not blocked by methylation
+	marked bool
+}
+
+func (i enzymeItem) Title() string {
+	t := fmt.Sprintf("%-8s %s", i.ec.Enzyme.Name, i.ec.Enzyme.Notation)
+	if i.marked {
+		return "✓ " + t
+	}
+	return t
+}
+
+func (i enzymeItem) Description() string {
+	var d string
+	switch len(i.cuts) {
+	case 0:
+		d = "no sites"
+	case 1:
+		d = fmt.Sprintf("cuts once, after %d", i.cuts[0].Position)
+	default:
+		positions := make([]string, 0, 8)
+		for _, c := range i.cuts[:min(8, len(i.cuts))] {
+			positions = append(positions, fmt.Sprint(c.Position))
+		}
+		if len(i.cuts) > 8 {
+			positions = append(positions, "...")
+		}
+		d = fmt.Sprintf("%d cuts, after %s", len(i.cuts), strings.Join(positions, ", "))
+	}
+	d += " - " + i.ec.Enzyme.Overhang()
+	if blocked := len(i.ec.Cuts) - len(i.cuts); blocked > 0 {
+		return d + fmt.Sprintf(" - %d sites blocked", blocked)
+	}
+	// sites that would be blocked by methylation of some kind
+	var kinds []string
+	for _, c := range i.cuts {
+		for _, k := range c.Blocked {
+			if !slices.Contains(kinds, k) {
+				kinds = append(kinds, k)
+			}
+		}
+	}
+	if len(kinds) > 0 {
+		d += " - " + strings.Join(kinds, "/") + " sensitive"
+	}
+	return d
+}
+
+func (i enzymeItem) FilterValue() string { return i.ec.Enzyme.Name + " " + i.ec.Enzyme.Site }
+
+type restrictionPage struct {
+	Title      string
+	Name       string
+	Length     int
+	Circular   bool
+	Methylated bool // template from a dam+ dcm+ E. coli strain
+	Digest     []EnzymeCuts
+	Filter     int // index into enzymeFilters
+	Marked     map[string]bool
+	Results    list.Model
+	Sequence   string
+}
+
+func NewRestrictionPage(seq GBSeq, width, height int) *restrictionPage {
+	d := list.NewDefaultDelegate()
+	d.UpdateFunc = UpdateDelegate
+
+	page := &restrictionPage{
+		Title:    "Restriction map of " + seq.PrimaryAccession,
+		Name:     CartItemFromSeq("nuccore", seq).Accession,
+		Length:   len(seq.Sequence),
+		Circular: strings.EqualFold(seq.Topology, "circular"),
+		Marked:   map[string]bool{},
+		Results:  list.New(nil, d, width, height-8),
+		Sequence: seq.Sequence,
+	}
+	page.digest()
+	return page
+}
+
+// digest recomputes the sites, for a new topology.
+func (page *restrictionPage) digest() {
+	page.Digest = Digest(page.Sequence, Enzymes, page.Circular)
+	page.refresh()
+}
+
+// cuts drops the sites methylation would block, when the template is
+// methylated. Only dam and dcm count: E. coli doesn't methylate CpG.
+func (page *restrictionPage) cuts(ec EnzymeCuts) []Cut {
+	if !page.Methylated {
+		return ec.Cuts
+	}
+	return slices.DeleteFunc(slices.Clone(ec.Cuts), func(c Cut) bool {
+		return slices.Contains(c.Blocked, "dam") || slices.Contains(c.Blocked, "dcm")
+	})
+}
+
+func (page *restrictionPage) refresh() {
+	var items []list.Item
+	for _, ec := range page.Digest {
+		cuts := page.cuts(ec)
+		keep := false
+		switch enzymeFilters[page.Filter] {
+		case "single cutters":
+			keep = len(cuts) == 1
+		case "double cutters":
+			keep = len(cuts) == 2
+		case "all cutters":
+			keep = len(cuts) > 0
+		case "non-cutters":
+			keep = len(cuts) == 0
+		default:
+			keep = true
+		}
+		if keep {
+			items = append(items, enzymeItem{ec: ec, cuts: cuts, marked: page.Marked[ec.Enzyme.Name]})
+		}
+	}
+	page.Results.SetItems(items)
+	page.Results.Title = fmt.Sprintf("%d %s of %d enzymes", len(items), enzymeFilters[page.Filter], len(page.Digest))
+}
+
+// digestLine lists the fragments from cutting with the marked enzymes, or
+// with the selected one if none are marked.
+func (page *restrictionPage) digestLine() string {
+	var names []string
+	var positions []int
+	for _, ec := range page.Digest {
+		if page.Marked[ec.Enzyme.Name] {
+			names = append(names, ec.Enzyme.Name)
+			for _, c := range page.cuts(ec) {
+				positions = append(positions, c.Position)
+			}
+		}
+	}
+	if len(names) == 0 {
+		item, ok := page.Results.SelectedItem().(enzymeItem)
+		if !ok {
+			return ""
+		}
+		names = []string{item.ec.Enzyme.Name}
+		for _, c := range item.cuts {
+			positions = append(positions, c.Position)
+		}
+	}
+
+	frags := Fragments(page.Length, positions, page.Circular)
+	if len(frags) == 0 {
+		return strings.Join(names, " + ") + " doesn't cut"
+	}
+	slices.SortFunc(frags, func(a, b int) int { return b - a })
+	sizes := make([]string, len(frags))
+	for idx, f := range frags {
+		sizes[idx] = fmt.Sprint(f)
+	}
+	return fmt.Sprintf("%s: %d fragments, %s bp", strings.Join(names, " + "), len(frags), strings.Join(sizes, ", "))
+}
+
+// UpdatePage implements page.
+func (page *restrictionPage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if page.Results.FilterState() == list.Filtering {
+			break
+		}
+		switch {
+		case msg.String() == "f":
+			page.Filter = (page.Filter + 1) % len(enzymeFilters)
+			page.refresh()
+			page.Results.ResetSelected()
+			return m, nil
+		case msg.String() == "m":
+			page.Methylated = !page.Methylated
+			page.refresh()
+			return m, nil
+		case msg.String() == "c":
+			page.Circular = !page.Circular
+			page.digest()
+			return m, nil
+		case msg.String() == "u":
+			page.Marked = map[string]bool{}
+			page.refresh()
+			return m, nil
+		case key.Matches(msg, m.Keys.Back) && page.Results.FilterState() == list.Unfiltered:
+			m.UpdateBack(msg)
+			return m, nil
+		}
+
+	case listMarkMsg:
+		if item, ok := page.Results.SelectedItem().(enzymeItem); ok {
+			page.Marked[item.ec.Enzyme.Name] = !page.Marked[item.ec.Enzyme.Name]
+			page.refresh()
+		}
+		return m, nil
+
+	case listSelectMsg:
+		return m, nil
+
+	case tea.WindowSizeMsg:
+		page.Results.SetSize(msg.Width-20, msg.Height-16)
+	}
+
+	var cmd tea.Cmd
+	page.Results, cmd = page.Results.Update(msg)
+	return m, cmd
+}
+
+// Page implements page.
+func (page *restrictionPage) Page(m Model) string {
+	topology := "linear"
+	if page.Circular {
+		topology = "circular"
+	}
+	template := "unmethylated template"
+	if page.Methylated {
+		template = "dam+ dcm+ template"
+	}
+	p := fmt.Sprintf("%s, %d bp, %s, %s\n", page.Name, page.Length, topology, template)
+	p += faintStyle.Render("f: single/double/all cutters • space: mark for a digest • u: unmark all • c: circular/linear • m: dam/dcm methylation • /: filter") + "\n\n"
+	p += page.Results.View()
+	if d := page.digestLine(); d != "" {
+		p += "\n" + d
+	}
+	return p + "\n\n"
+}
+
+func (page *restrictionPage) GetTitle() string {
+	return page.Title
+}
@@ -0,0 +1,216 @@
+package internal
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+//go:embed rebase.txt
+var rebaseData string
+
+// Enzyme is a restriction enzyme. Cuts are counted in bases from the start
+// of the site along the top strand, so G^AATTC cuts at 1 and 5.
+type Enzyme struct {
+	Name       string
+	Notation   string // the site as REBASE writes it, G^AATTC or GGTCTC(1/5)
+	Site       string
+	Cut5, Cut3 int      // top and bottom strand
+	Blocked    []string // dam, dcm or CpG
+	re, rcRe   *regexp.Regexp
+}
+
+// Enzymes is the embedded enzyme set.
+var Enzymes = mustParseEnzymes(rebaseData)
+
+// ParseEnzyme reads a site in REBASE notation.
+func ParseEnzyme(name, notation string) (Enzyme, error) {
+	e := Enzyme{Name: name, Notation: notation}
+	site, offsets, outside := strings.Cut(notation, "(")
+	switch {
+	case outside:
+		n, m, ok := strings.Cut(strings.TrimSuffix(offsets, ")"), "/")
+		top, err1 := strconv.Atoi(n)
+		bottom, err2 := strconv.Atoi(m)
+		if !ok || err1 != nil || err2 != nil {
+			return e, fmt.Errorf("%s: bad cut offsets %q", name, offsets)
+		}
+		e.Site = site
+		e.Cut5, e.Cut3 = len(site)+top, len(site)+bottom
+	case strings.Count(site, "^") == 1:
+		e.Cut5 = strings.Index(site, "^")
+		e.Site = strings.Replace(site, "^", "", 1)
+		// ^ alone is only used for palindromes, cut symmetrically
+		e.Cut3 = len(e.Site) - e.Cut5
+	default:
+		return e, fmt.Errorf("%s: no cut position in %q", name, notation)
+	}
+
+	expr, err := iupacRegexp(e.Site, false)
+	if err != nil {
+		return e, fmt.Errorf("%s: %v", name, err)
+	}
+	e.re = regexp.MustCompile(expr)
+	if rc := ReverseComplement(e.Site); rc != e.Site {
+		expr, _ = iupacRegexp(rc, false)
+		e.rcRe = regexp.MustCompile(expr)
+	}
+	return e, nil
+}
+
+func mustParseEnzymes(data string) []Enzyme {
+	var enzymes []Enzyme
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		e, err := ParseEnzyme(fields[0], fields[1])
+		if err != nil {
+			panic(err)
+		}
+		if len(fields) > 2 && fields[2] != "-" {
+			e.Blocked = strings.Split(fields[2], ",")
+		}
+		enzymes = append(enzymes, e)
+	}
+	return enzymes
+}
+
+// Overhang describes the ends the enzyme leaves.
+func (e Enzyme) Overhang() string {
+	switch d := e.Cut3 - e.Cut5; {
+	case d > 0:
+		return fmt.Sprintf("5' overhang, %d nt", d)
+	case d < 0:
+		return fmt.Sprintf("3' overhang, %d nt", -d)
+	}
+	return "blunt"
+}
+
+// methylation motifs, for checking whether one overlaps a site
+var methylationSites = map[string]*regexp.Regexp{
+	"dam": regexp.MustCompile("GATC"),
+	"dcm": regexp.MustCompile("CC[AT]GG"),
+	"CpG": regexp.MustCompile("CG"),
+}
+
+// Cut is one site of an enzyme.
+type Cut struct {
+	Site     int      // 1-based start of the recognition site
+	Strand   string   // + or -, the strand the site reads on
+	Position int      // the top strand is cut after this base
+	Blocked  []string // methylation overlapping this site
+}
+
+type EnzymeCuts struct {
+	Enzyme Enzyme
+	Cuts   []Cut
+}
+
+// Digest finds every site of every enzyme. On a circular sequence sites and
+// cuts may span the origin; on a linear one, cuts that would fall off either
+// end are dropped.
+func Digest(seq string, enzymes []Enzyme, circular bool) []EnzymeCuts {
+	seq = strings.ReplaceAll(strings.ToUpper(seq), "U", "T")
+	n := len(seq)
+	out := make([]EnzymeCuts, len(enzymes))
+	for idx, e := range enzymes {
+		out[idx] = EnzymeCuts{Enzyme: e, Cuts: siteCuts(seq, e, circular)}
+		if n == 0 {
+			out[idx].Cuts = nil
+		}
+	}
+	return out
+}
+
+func siteCuts(seq string, e Enzyme, circular bool) []Cut {
+	n, l := len(seq), len(e.Site)
+	scan := seq
+	if circular {
+		scan += seq[:min(n, l-1)]
+	}
+
+	var cuts []Cut
+	add := func(re *regexp.Regexp, strand string) {
+		for pos := 0; pos < n; {
+			loc := re.FindStringIndex(scan[pos:])
+			if loc == nil || pos+loc[0] >= n {
+				break
+			}
+			a := pos + loc[0]
+			pos = a + 1
+
+			// the top strand cut, which for a site on the reverse strand is
+			// the enzyme's bottom strand cut
+			cut := a + e.Cut5
+			if strand == "-" {
+				cut = a + l - e.Cut3
+			}
+			switch {
+			case circular:
+				cut = (cut%n + n) % n
+				if cut == 0 {
+					cut = n
+				}
+			case cut <= 0 || cut >= n:
+				continue
+			}
+			cuts = append(cuts, Cut{Site: a + 1, Strand: strand, Position: cut, Blocked: e.blockedAt(scan, a, a+l)})
+		}
+	}
+	add(e.re, "+")
+	if e.rcRe != nil {
+		add(e.rcRe, "-")
+	}
+	slices.SortFunc(cuts, func(a, b Cut) int { return a.Position - b.Position })
+	return cuts
+}
+
+// blockedAt lists the methylation the enzyme is sensitive to that overlaps
+// the site at [a, b).
+func (e Enzyme) blockedAt(seq string, a, b int) []string {
+	var out []string
+	for _, kind := range e.Blocked {
+		re := methylationSites[kind]
+		// motifs are at most 5 bases, so 4 either side is enough context
+		from, to := max(0, a-4), min(len(seq), b+4)
+		for _, loc := range re.FindAllStringIndex(seq[from:to], -1) {
+			if from+loc[1] > a && from+loc[0] < b {
+				out = append(out, kind)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Fragments gives the fragment sizes, in order along the sequence, from
+// cuts after the given bases. A circular sequence cut once is linearised
+// into one fragment of full length, and one never cut gives none.
+func Fragments(length int, positions []int, circular bool) []int {
+	positions = slices.Clone(positions)
+	slices.Sort(positions)
+	positions = slices.Compact(positions)
+	if circular {
+		if len(positions) == 0 {
+			return nil
+		}
+		sizes := make([]int, len(positions))
+		for i := 1; i < len(positions); i++ {
+			sizes[i-1] = positions[i] - positions[i-1]
+		}
+		sizes[len(positions)-1] = length - positions[len(positions)-1] + positions[0]
+		return sizes
+	}
+	sizes := make([]int, 0, len(positions)+1)
+	prev := 0
+	for _, p := range positions {
+		sizes = append(sizes, p-prev)
+		prev = p
+	}
+	return append(sizes, length-prev)
+}
@@ -2,14 +2,17 @@ package internal
 
 import (
 	"fmt"
-	"log"
 	"os"
+	"path/filepath"
 	"strings"
 
+	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
 var (
@@ -26,12 +29,40 @@ var (
 	}()
 )
 
+// what the detail page's viewport shows
+type seqView int
+
+const (
+	recordView seqView = iota
+	framesView
+	selectionView
+	motifView
+	primerView
+)
+
 type seqResPage struct {
-	Data     GBSeq
-	Viewport viewport.Model
-	Title    string
-	Id       string
-	Width    int
+	Data      GBSeq
+	Viewport  viewport.Model
+	Title     string
+	Id        string
+	Width     int
+	Status    string
+	Source    string // local file the record came from, empty for NCBI
+	Format    string // format of the local file
+	Saved     bool   // opened from the library
+	Partial   bool   // a search result, cut down to the first base
+	View      seqView
+	Code      GeneticCode // for translations, picked from the record until changed
+	Range     textinput.Model
+	Selecting bool // typing a range
+	Selection Selection
+	Search    textinput.Model
+	Searching bool // typing a motif
+	Motif     *Motif
+	Hits      []MotifHit
+	Hit       int   // current hit, for n and N
+	hitLines  []int // viewport line of each hit
+	Window    int   // index into hydropathyWindows, for proteins
 }
 
 func NewSeqResPage(data GBSeq, id string, title string, width, height int) *seqResPage {
@@ -41,13 +72,373 @@ func NewSeqResPage(data GBSeq, id string, title string, width, height int) *seqR
 
 	v := viewport.New(width, height-verticalMarginHeight)
 	v.YPosition = headerHeight
-	v.SetContent(data.PrettyPrint())
-	return &seqResPage{
+
+	r := textinput.New()
+	r.Prompt = "Range: "
+	r.Placeholder = "1200-1850, or 1850-1200 for the reverse strand"
+	r.Width = 50
+
+	search := textinput.New()
+	search.Prompt = "Motif: "
+	search.Placeholder = "TATAWAWR, C-x(2)-C-x(3)-[LIVM]-H, or /regex/"
+	search.Width = 50
+
+	page := &seqResPage{
 		Data:     data,
 		Viewport: v,
 		Title:    title,
 		Id:       title,
 		Width:    width,
+		Range:    r,
+		Search:   search,
+	}
+	page.refresh()
+	return page
+}
+
+// complete reports whether the whole sequence is loaded.
+func (page *seqResPage) complete() bool {
+	return !page.Partial && page.Data.Sequence != ""
+}
+
+// refresh re-renders the current view into the viewport, keeping the scroll
+// position.
+func (page *seqResPage) refresh() {
+	offset := page.Viewport.YOffset
+	switch page.View {
+	case framesView:
+		page.Viewport.SetContent(page.framesContent())
+	case selectionView:
+		page.Viewport.SetContent(page.selectionContent())
+	case motifView:
+		page.Viewport.SetContent(page.motifContent())
+	case primerView:
+		page.Viewport.SetContent(page.primerContent())
+	default:
+		page.Viewport.SetContent(page.recordContent())
+	}
+	page.Viewport.SetYOffset(offset)
+}
+
+// setView switches views, starting the new one at the top.
+func (page *seqResPage) setView(v seqView) {
+	if page.View == v {
+		v = recordView
+	}
+	page.View = v
+	page.refresh()
+	page.Viewport.GotoTop()
+}
+
+func (page *seqResPage) recordContent() string {
+	data := page.Data
+	stats := faintStyle.Render("s: load the sequence to see statistics")
+	if page.complete() {
+		stats = StatsReport(data.Sequence, IsProtein(data), page.Width)
+		if IsProtein(data) {
+			stats += "\n" + ProteinReport(data.Sequence, hydropathyWindows[page.Window], page.Width)
+			stats += faintStyle.Render("H: hydropathy window") + "\n"
+		}
+	} else {
+		// a cut down record only holds the first base
+		data.Sequence = ""
+	}
+	return data.PrettyPrint(stats)
+}
+
+func (page *seqResPage) framesContent() string {
+	switch {
+	case !page.complete():
+		return faintStyle.Render("s: load the sequence to translate it")
+	case IsProtein(page.Data):
+		return faintStyle.Render("this is already a protein sequence")
+	}
+	if page.Code.ID == 0 {
+		page.Code = RecordGeneticCode(page.Data)
+	}
+	s := "=== SIX-FRAME TRANSLATION ===\n"
+	s += fmt.Sprintf("Genetic code %s\n", page.Code)
+	s += faintStyle.Render("g: next genetic code • o: find ORFs • t: back to the record") + "\n\n"
+	return s + SixFrameView(page.Data.Sequence, page.Code, page.Width)
+}
+
+// selectionRecord is the selected range as FASTA, named after the record.
+func (page *seqResPage) selectionRecord() FastaRecord {
+	acc := CartItemFromSeq("nuccore", page.Data).Accession
+	return page.Selection.Record(acc, page.Data.Definition, page.Data.Sequence)
+}
+
+func (page *seqResPage) selectionContent() string {
+	var sb strings.Builder
+	padding := fmt.Sprintf("%%-%ds", LabelPadding)
+	sel := page.Selection
+	strand := "forward"
+	if sel.Reverse {
+		strand = "reverse complement"
+	}
+
+	sb.WriteString("=== SELECTION ===\n")
+	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Source:", CartItemFromSeq("nuccore", page.Data).Accession))
+	sb.WriteString(fmt.Sprintf(padding+" %d-%d, %d %s\n", "Range:", sel.Start, sel.End, sel.Len(), lengthUnit(page.Data)))
+	if !IsProtein(page.Data) {
+		sb.WriteString(fmt.Sprintf(padding+" %s\n", "Strand:", strand))
+	}
+	help := "w: save as FASTA • y: copy • v: new range • bksp: back to the record"
+	if !IsProtein(page.Data) {
+		help = "r: reverse complement • p: analyze as a primer • P: pick primers around it • " + help
+	}
+	sb.WriteString(faintStyle.Render(help) + "\n\n")
+	WriteFasta(&sb, []FastaRecord{page.selectionRecord()})
+	return sb.String()
+}
+
+// primerContent analyzes the selection as an oligo, read as selected.
+func (page *seqResPage) primerContent() string {
+	rec := page.selectionRecord()
+	p, err := AnalyzePrimer(rec.Sequence, DefaultConditions)
+	help := faintStyle.Render("bksp: back to the selection")
+	if err != nil {
+		return errorStyle.Render(err.Error()) + "\n" + help
+	}
+	return PrimerReport(rec.ID, p, DefaultConditions) + "\n" + help
+}
+
+func (page *seqResPage) motifContent() string {
+	var sb strings.Builder
+	padding := fmt.Sprintf("%%-%ds", LabelPadding)
+	protein := IsProtein(page.Data)
+	mo, hits := page.Motif, page.Hits
+
+	where := "both strands"
+	if protein {
+		where = "protein"
+	}
+	sb.WriteString("=== MOTIF SEARCH ===\n")
+	sb.WriteString(fmt.Sprintf(padding+" %s (%s, %s)\n", "Pattern:", mo.Pattern, mo.Kind, where))
+	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Regexp:", mo.Expr))
+	count := fmt.Sprint(len(hits))
+	if !protein {
+		var fwd int
+		for _, h := range hits {
+			if h.Strand == "+" {
+				fwd++
+			}
+		}
+		count += fmt.Sprintf(" (%d +, %d -)", fwd, len(hits)-fwd)
+	}
+	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Hits:", count))
+	sb.WriteString(faintStyle.Render("n/N: next/previous hit • /: new search • bksp: back to the record") + "\n")
+
+	if len(hits) > 0 {
+		// a window of the hit list around the current hit
+		sb.WriteString("\n--- HITS ---\n")
+		from := max(0, min(page.Hit-10, len(hits)-50))
+		to := min(from+50, len(hits))
+		if from > 0 {
+			sb.WriteString(faintStyle.Render(fmt.Sprintf("  ... %d before", from)) + "\n")
+		}
+		for idx := from; idx < to; idx++ {
+			h := hits[idx]
+			match := h.Match
+			if len(match) > 40 {
+				match = match[:37] + "..."
+			}
+			line := fmt.Sprintf("  %5d  %-21s %1s  %s", idx+1, fmt.Sprintf("%d-%d", h.Start, h.End), h.Strand, match)
+			if idx == page.Hit {
+				line = selectedRowStyle.Render(line)
+			}
+			sb.WriteString(line + "\n")
+		}
+		if to < len(hits) {
+			sb.WriteString(faintStyle.Render(fmt.Sprintf("  ... and %d more", len(hits)-to)) + "\n")
+		}
+	}
+
+	sb.WriteString("\n--- SEQUENCE ---\n")
+	top := strings.Count(sb.String(), "\n")
+	text, lines := HighlightSequence(page.Data.Sequence, hits, page.Hit)
+	for idx := range lines {
+		lines[idx] += top
+	}
+	page.hitLines = lines
+	return sb.String() + text
+}
+
+// jump moves to a hit, wrapping around at either end, and scrolls it into
+// view.
+func (page *seqResPage) jump(by int) {
+	if len(page.Hits) == 0 {
+		return
+	}
+	page.Hit = (page.Hit + by + len(page.Hits)) % len(page.Hits)
+	page.refresh()
+	page.Viewport.SetYOffset(max(0, page.hitLines[page.Hit]-3))
+	h := page.Hits[page.Hit]
+	page.Status = fmt.Sprintf("hit %d of %d: %d-%d %s", page.Hit+1, len(page.Hits), h.Start, h.End, h.Strand)
+}
+
+// copyText puts text on the system clipboard, or failing that, asks the
+// terminal to (OSC 52), which also works over ssh.
+func copyText(text string) string {
+	if err := clipboard.WriteAll(text); err != nil {
+		termenv.Copy(text)
+		return "terminal clipboard"
+	}
+	return "clipboard"
+}
+
+func NewLocalSeqResPage(data GBSeq, path, format string, width, height int) *seqResPage {
+	page := NewSeqResPage(data, data.PrimaryAccession, data.PrimaryAccession, width, height)
+	page.Source = path
+	page.Format = format
+	return page
+}
+
+// exportRecord writes the record to dir as EMBL, or as GenBank if it was read
+// from an EMBL file, and returns the path written.
+func exportRecord(seq GBSeq, from, dir string, overwrite bool) (string, error) {
+	name := seq.AccessionVersion
+	if name == "" {
+		name = seq.PrimaryAccession
+	}
+	write, ext := WriteEMBL, ".embl"
+	if from == "embl" {
+		write, ext = WriteGenBank, ".gb"
+	}
+	path := filepath.Join(dir, name+ext)
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return "", fmt.Errorf("%s already exists", path)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	err = write(f, seq)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return path, err
+}
+
+type exportedMsg struct {
+	accession string
+	path      string
+	err       error
+}
+
+// exportComplete exports the record, fetching the whole of it first when
+// it's a search result.
+func exportComplete(database string, seq GBSeq, complete bool, from, dir string, overwrite bool) tea.Cmd {
+	acc := CartItemFromSeq(database, seq).Accession
+	return func() tea.Msg {
+		rec, err := fetchComplete(database, seq, complete)
+		if err != nil {
+			return exportedMsg{accession: acc, err: err}
+		}
+		path, err := exportRecord(rec, from, dir, overwrite)
+		return exportedMsg{accession: acc, path: path, err: err}
+	}
+}
+
+// exportFasta writes records to dir/name, returning the path written.
+func exportFasta(recs []FastaRecord, dir, name string, overwrite bool) (string, error) {
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err == nil && !overwrite {
+		return "", fmt.Errorf("%s already exists", path)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	err = WriteFasta(f, recs)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return path, err
+}
+
+type librarySaveMsg struct {
+	database  string
+	accession string
+	record    GBSeq
+	err       error
+}
+
+type librarySavedMsg struct {
+	accession string
+	err       error
+}
+
+// fetchComplete returns the record with its whole sequence, fetching it
+// again unless it's already complete: search results only carry the first
+// base.
+func fetchComplete(database string, seq GBSeq, complete bool) (GBSeq, error) {
+	if complete {
+		return seq, nil
+	}
+	acc := CartItemFromSeq(database, seq).Accession
+	recs, err := EFetch(database, []string{acc}, true)
+	if err == nil && len(recs) == 0 {
+		err = fmt.Errorf("%s not found", acc)
+	}
+	if err != nil {
+		return GBSeq{}, err
+	}
+	return recs[0], nil
+}
+
+func saveToLibrary(database string, seq GBSeq, complete bool) tea.Cmd {
+	acc := CartItemFromSeq(database, seq).Accession
+	return func() tea.Msg {
+		rec, err := fetchComplete(database, seq, complete)
+		if err != nil {
+			return librarySaveMsg{accession: acc, err: err}
+		}
+		return librarySaveMsg{database: database, accession: acc, record: rec}
+	}
+}
+
+type alignPickMsg struct {
+	accession string
+	record    GBSeq
+	err       error
+}
+
+type alignPickedMsg struct {
+	accession string
+	picked    int // records picked so far
+	err       error
+}
+
+func pickForAlignment(database string, seq GBSeq, complete bool) tea.Cmd {
+	acc := CartItemFromSeq(database, seq).Accession
+	return func() tea.Msg {
+		rec, err := fetchComplete(database, seq, complete)
+		return alignPickMsg{accession: acc, record: rec, err: err}
+	}
+}
+
+// pickedStatus reports a pick on whichever page made it.
+func pickedStatus(msg alignPickedMsg) string {
+	if msg.err != nil {
+		return "picking failed: " + msg.err.Error()
+	}
+	return fmt.Sprintf("picked %s for alignment (%d of 2)", msg.accession, msg.picked)
+}
+
+type sequenceMsg struct {
+	page   *seqResPage
+	record GBSeq
+	err    error
+}
+
+// loadSequence fetches the complete record for a search result. The result
+// goes back to this page even if the user has moved on.
+func (page *seqResPage) loadSequence(database string) tea.Cmd {
+	seq := page.Data
+	return func() tea.Msg {
+		rec, err := fetchComplete(database, seq, false)
+		return sequenceMsg{page: page, record: rec, err: err}
 	}
 }
 
@@ -62,35 +453,243 @@ func footerView(width int) string {
 	return line
 }
 
-func fetchSeq(id string) func() tea.Msg {
-	return func() tea.Msg {
-		// fetch single result
-		res, err := EFetch("nuccore", []string{id}, true)
-		if err != nil {
-			return errMsg{err: err}
-		}
-		err = os.WriteFile("./sequence.txt", []byte(res[0].Sequence), 0644)
-		if err != nil {
-			return errMsg{err: err}
-		}
-		return entrezMsg{
-			result: res,
-		}
+// updateRange handles typing in the range prompt. Enter selects the range,
+// or cancels when it's empty.
+func (page *seqResPage) updateRange(msg tea.KeyMsg) tea.Cmd {
+	if msg.String() != "enter" {
+		var cmd tea.Cmd
+		page.Range, cmd = page.Range.Update(msg)
+		return cmd
+	}
+	page.Selecting = false
+	page.Range.Blur()
+	if strings.TrimSpace(page.Range.Value()) == "" {
+		return nil
+	}
+	sel, err := ParseSelection(page.Range.Value(), len(page.Data.Sequence))
+	if err == nil && sel.Reverse && IsProtein(page.Data) {
+		err = fmt.Errorf("a protein has no reverse strand")
+	}
+	if err != nil {
+		page.Status = err.Error()
+		return nil
+	}
+	page.Selection = sel
+	page.Status = ""
+	if page.View == selectionView {
+		page.refresh()
+	} else {
+		page.setView(selectionView)
+	}
+	return nil
+}
+
+// updateSearch handles typing in the motif prompt. Enter runs the search,
+// or cancels when it's empty.
+func (page *seqResPage) updateSearch(msg tea.KeyMsg) tea.Cmd {
+	if msg.String() != "enter" {
+		var cmd tea.Cmd
+		page.Search, cmd = page.Search.Update(msg)
+		return cmd
+	}
+	page.Searching = false
+	page.Search.Blur()
+	if strings.TrimSpace(page.Search.Value()) == "" {
+		return nil
+	}
+	mo, err := CompileMotif(page.Search.Value(), IsProtein(page.Data))
+	if err != nil {
+		page.Status = err.Error()
+		return nil
 	}
+	page.Motif = mo
+	page.Hits = mo.Search(page.Data.Sequence, IsProtein(page.Data))
+	page.Hit = 0
+	page.Status = fmt.Sprintf("%d hits", len(page.Hits))
+	if page.View == motifView {
+		page.refresh()
+	} else {
+		page.setView(motifView)
+	}
+	page.jump(0)
+	return nil
 }
 
 // UpdatePage implements page.
 func (page *seqResPage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if page.Selecting {
+			return m, page.updateRange(msg)
+		}
+		if page.Searching {
+			return m, page.updateSearch(msg)
+		}
+		if key.Matches(msg, m.Keys.Export) {
+			page.Status = "exporting ..."
+			return m, exportComplete("nuccore", page.Data, page.complete(), page.Format, m.Downloads.Dir, m.Downloads.Overwrite)
+		}
+		switch {
+		case msg.String() == "t":
+			page.setView(framesView)
+			return m, nil
+		case msg.String() == "g" && page.View == framesView && page.Code.ID != 0:
+			for idx, gc := range GeneticCodes {
+				if gc.ID == page.Code.ID {
+					page.Code = GeneticCodes[(idx+1)%len(GeneticCodes)]
+					break
+				}
+			}
+			page.refresh()
+			return m, nil
+		case msg.String() == "/" && page.complete():
+			page.Searching = true
+			return m, page.Search.Focus()
+		case (msg.String() == "n" || msg.String() == "N") && page.View == motifView:
+			if msg.String() == "n" {
+				page.jump(1)
+			} else {
+				page.jump(-1)
+			}
+			return m, nil
+		case msg.String() == "v" && page.complete():
+			page.Selecting = true
+			return m, page.Range.Focus()
+		case msg.String() == "p" && page.View == selectionView && !IsProtein(page.Data):
+			page.setView(primerView)
+			return m, nil
+		case msg.String() == "P" && page.View == selectionView && !IsProtein(page.Data):
+			m.Pages[PrimerPage] = NewPrimerPage(page.Data, page.Selection, m.Width-20, m.Height-8)
+			m.UpdateHistory(m.Page, page.Title)
+			m.Page = PrimerPage
+			return m, nil
+		case key.Matches(msg, m.Keys.Back) && page.View == primerView:
+			page.setView(selectionView)
+			return m, nil
+		case msg.String() == "r" && page.View == selectionView && !IsProtein(page.Data):
+			page.Selection.Reverse = !page.Selection.Reverse
+			page.refresh()
+			return m, nil
+		case msg.String() == "w" && page.View == selectionView:
+			rec := page.selectionRecord()
+			name := strings.ReplaceAll(rec.ID, ":", "_") + ".fa"
+			if path, err := exportFasta([]FastaRecord{rec}, m.Downloads.Dir, name, m.Downloads.Overwrite); err != nil {
+				page.Status = "save failed: " + err.Error()
+			} else {
+				page.Status = "saved " + rec.ID + " to " + path
+			}
+			return m, nil
+		case msg.String() == "y" && page.View == selectionView:
+			var sb strings.Builder
+			WriteFasta(&sb, []FastaRecord{page.selectionRecord()})
+			page.Status = "copied " + page.selectionRecord().ID + " to the " + copyText(sb.String())
+			return m, nil
+		case key.Matches(msg, m.Keys.Back) && page.View != recordView:
+			page.setView(recordView)
+			return m, nil
+		case msg.String() == "R" && page.complete() && !IsProtein(page.Data):
+			m.Pages[RestrictionPage] = NewRestrictionPage(page.Data, m.Width-20, m.Height-8)
+			m.UpdateHistory(m.Page, page.Title)
+			m.Page = RestrictionPage
+			return m, nil
+		case msg.String() == "H" && page.View == recordView && page.complete() && IsProtein(page.Data):
+			page.Window = (page.Window + 1) % len(hydropathyWindows)
+			page.refresh()
+			return m, nil
+		case msg.String() == "D" && page.complete():
+			dp := NewDotPlotPage(page.Data, page.Data, m.Width-20, m.Height-8)
+			m.Pages[DotPlotPage] = dp
+			m.UpdateHistory(m.Page, page.Title)
+			m.Page = DotPlotPage
+			return m, dp.Start()
+		case msg.String() == "u" && !IsProtein(page.Data):
+			page.Status = "counting codons ..."
+			acc := CartItemFromSeq("nuccore", page.Data).Accession
+			return m, countCodons("nuccore", acc, []GBSeq{page.Data}, page.complete())
+		case msg.String() == "o" && page.complete() && !IsProtein(page.Data):
+			if page.Code.ID == 0 {
+				page.Code = RecordGeneticCode(page.Data)
+			}
+			m.Pages[ORFPage] = NewORFPage(page.Data, page.Code, m.Width-20, m.Height-8)
+			m.UpdateHistory(m.Page, page.Title)
+			m.Page = ORFPage
+			return m, nil
+		}
+		if msg.String() == "s" && !page.complete() {
+			page.Status = "loading sequence ..."
+			return m, page.loadSequence("nuccore")
+		}
+		if key.Matches(msg, m.Keys.Pick) {
+			page.Status = "picking for alignment ..."
+			return m, pickForAlignment("nuccore", page.Data, page.complete())
+		}
+		if key.Matches(msg, m.Keys.Save) {
+			if page.Saved {
+				page.Status = "already in the library"
+				return m, nil
+			}
+			page.Status = "saving to library ..."
+			return m, saveToLibrary("nuccore", page.Data, page.complete())
+		}
+		if key.Matches(msg, m.Keys.Dl) && page.Source != "" {
+			page.Status = "already on disk: " + page.Source
+			return m, nil
+		}
 		if key.Matches(msg, m.Keys.Dl) {
-			return m, fetchSeq(page.Id)
+			job := NewDownloadJob("nuccore", page.Data)
+			cmd := m.Downloads.Enqueue(job)
+			page.Status = fmt.Sprintf("queued %s → %s (ctrl-o to view downloads)", job.Ids[0], job.Path)
+			return m, cmd
 		}
 		m.UpdateBack(msg)
-	case entrezMsg:
-		// show a dialog or message that the result was downloaded
-	case errMsg:
-		log.Fatalf("error fetching result sequence: %s", msg.err)
+
+	case sequenceMsg:
+		if msg.err != nil {
+			page.Status = "loading failed: " + msg.err.Error()
+			return m, nil
+		}
+		page.Data = msg.record
+		page.Partial = false
+		page.Code = GeneticCode{}
+		page.Status = ""
+		page.refresh()
+		return m, nil
+
+	case alignPickedMsg:
+		page.Status = pickedStatus(msg)
+		return m, nil
+
+	case codonUsageMsg:
+		if msg.name != CartItemFromSeq("nuccore", page.Data).Accession {
+			break
+		}
+		page.Status = ""
+		if err := openCodonPage(&m, msg, page.Title); err != nil {
+			page.Status = "codon usage failed: " + err.Error()
+		}
+		return m, nil
+
+	case exportedMsg:
+		if msg.accession != CartItemFromSeq("nuccore", page.Data).Accession {
+			break
+		}
+		if msg.err != nil {
+			page.Status = "export failed: " + msg.err.Error()
+		} else {
+			page.Status = "exported to " + msg.path
+		}
+		return m, nil
+
+	case librarySavedMsg:
+		if msg.accession != CartItemFromSeq("nuccore", page.Data).Accession {
+			break
+		}
+		if msg.err != nil {
+			page.Status = "saving failed: " + msg.err.Error()
+		} else {
+			page.Status = "saved " + msg.accession + " to the library"
+		}
+		return m, nil
 	}
 
 	var cmd tea.Cmd
@@ -101,7 +700,17 @@ func (page *seqResPage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
 
 // Page implements page.
 func (page *seqResPage) Page(m Model) string {
-	return fmt.Sprintf("%s\n%s\n%s", headerView(page.Title, page.Width), page.Viewport.View(), footerView(page.Width))
+	s := fmt.Sprintf("%s\n%s\n%s", headerView(page.Title, page.Width), page.Viewport.View(), footerView(page.Width))
+	if page.Selecting {
+		s += "\n" + page.Range.View()
+	}
+	if page.Searching {
+		s += "\n" + page.Search.View()
+	}
+	if page.Status != "" {
+		s += "\n" + faintStyle.Render(page.Status)
+	}
+	return s
 }
 
 func (page *seqResPage) GetTitle() string {
@@ -0,0 +1,238 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SeqStats summarises a nucleotide or protein sequence.
+type SeqStats struct {
+	Protein   bool
+	Length    int
+	Counts    map[byte]int // per upper-cased letter
+	GC        float64      // percent of G+C among bases, 0 for proteins
+	N         int          // N for nucleotides, X for proteins
+	Ambiguous int          // other IUPAC ambiguity codes
+	Gaps      int
+	Unknown   int // characters that aren't IUPAC at all
+	MW        float64
+}
+
+// average masses in Da: nucleotide monophosphates and free amino acids. A
+// water is lost for every bond in the chain.
+var (
+	dnaWeights = map[byte]float64{'A': 331.2218, 'C': 307.1971, 'G': 347.2212, 'T': 322.2085}
+	rnaWeights = map[byte]float64{'A': 347.2212, 'C': 323.1965, 'G': 363.2206, 'U': 324.1813}
+
+	proteinWeights = map[byte]float64{
+		'A': 89.0932, 'C': 121.1582, 'D': 133.1027, 'E': 147.1293, 'F': 165.1891,
+		'G': 75.0666, 'H': 155.1546, 'I': 131.1729, 'K': 146.1876, 'L': 131.1729,
+		'M': 149.2113, 'N': 132.1179, 'O': 255.3134, 'P': 115.1305, 'Q': 146.1445,
+		'R': 174.201, 'S': 105.0926, 'T': 119.1192, 'U': 168.0532, 'V': 117.1463,
+		'W': 204.2252, 'Y': 181.1885,
+	}
+)
+
+const waterWeight = 18.0153
+
+// IUPAC nucleotide codes and the bases they stand for
+var iupacBases = map[byte]string{
+	'A': "A", 'C': "C", 'G': "G", 'T': "T", 'U': "T",
+	'R': "AG", 'Y': "CT", 'S': "CG", 'W': "AT", 'K': "GT", 'M': "AC",
+	'B': "CGT", 'D': "AGT", 'H': "ACT", 'V': "ACG", 'N': "ACGT",
+}
+
+// protein ambiguity codes: Asx, Glx, Xle
+var proteinAmbiguous = map[byte]string{'B': "DN", 'Z': "EQ", 'J': "IL"}
+
+func ComputeSeqStats(seq string, protein bool) SeqStats {
+	st := SeqStats{Protein: protein, Length: len(seq), Counts: map[byte]int{}}
+	for idx := 0; idx < len(seq); idx++ {
+		st.Counts[upper(seq[idx])]++
+	}
+
+	rna := st.Counts['U'] > 0 && st.Counts['T'] == 0
+	weights := dnaWeights
+	if rna {
+		weights = rnaWeights
+	}
+	if protein {
+		weights = proteinWeights
+	}
+
+	var gc, at, residues int
+	for c, n := range st.Counts {
+		switch {
+		case c == '-' || c == '.' || c == '*':
+			st.Gaps += n
+			continue
+		case protein && c == 'X', !protein && c == 'N':
+			st.N += n
+		}
+
+		// ambiguous letters weigh the average of what they stand for
+		var options string
+		if protein {
+			if _, ok := proteinWeights[c]; ok {
+				options = string(c)
+			} else {
+				options = proteinAmbiguous[c]
+			}
+			if c == 'X' {
+				options = "ACDEFGHIKLMNPQRSTVWY"
+			}
+		} else {
+			options = iupacBases[c]
+			if rna {
+				options = strings.ReplaceAll(options, "T", "U")
+			}
+		}
+		if options == "" {
+			st.Unknown += n
+			continue
+		}
+		if len(options) > 1 && c != 'N' && c != 'X' {
+			st.Ambiguous += n
+		}
+		w := 0.0
+		for idx := 0; idx < len(options); idx++ {
+			w += weights[options[idx]]
+		}
+		st.MW += w / float64(len(options)) * float64(n)
+		residues += n
+
+		switch c {
+		case 'G', 'C', 'S':
+			gc += n
+		case 'A', 'T', 'U', 'W':
+			at += n
+		}
+	}
+	if residues > 1 {
+		st.MW -= float64(residues-1) * waterWeight
+	}
+	if !protein && gc+at > 0 {
+		st.GC = 100 * float64(gc) / float64(gc+at)
+	}
+	return st
+}
+
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
+
+// GCWindows slides a window along the sequence and returns the GC percent
+// and GC skew, (G-C)/(G+C), of each. Windows without any G or C have zero
+// skew.
+func GCWindows(seq string, window, step int) (gc, skew []float64) {
+	if window <= 0 || step <= 0 {
+		return nil, nil
+	}
+	for start := 0; start+window <= len(seq) || (start == 0 && len(seq) > 0); start += step {
+		end := min(start+window, len(seq))
+		var g, c, at int
+		for idx := start; idx < end; idx++ {
+			switch upper(seq[idx]) {
+			case 'G':
+				g++
+			case 'C':
+				c++
+			case 'A', 'T', 'U':
+				at++
+			}
+		}
+		if g+c+at == 0 {
+			gc = append(gc, 0)
+		} else {
+			gc = append(gc, 100*float64(g+c)/float64(g+c+at))
+		}
+		if g+c == 0 {
+			skew = append(skew, 0)
+		} else {
+			skew = append(skew, float64(g-c)/float64(g+c))
+		}
+	}
+	return gc, skew
+}
+
+// StatsReport renders the statistics panel, with GC sparklines width
+// characters wide for nucleotide sequences.
+func StatsReport(seq string, protein bool, width int) string {
+	var sb strings.Builder
+	padding := fmt.Sprintf("%%-%ds", LabelPadding)
+	st := ComputeSeqStats(seq, protein)
+
+	sb.WriteString("--- STATISTICS ---\n")
+	unit := "bp"
+	if protein {
+		unit = "aa"
+	}
+	sb.WriteString(fmt.Sprintf(padding+" %d %s\n", "Length:", st.Length, unit))
+	if !protein {
+		sb.WriteString(fmt.Sprintf(padding+" %.2f%%\n", "GC content:", st.GC))
+	}
+	nLabel := "N:"
+	if protein {
+		nLabel = "X:"
+	}
+	sb.WriteString(fmt.Sprintf(padding+" %d (%.2f%%)\n", nLabel, st.N, percent(st.N, st.Length)))
+	sb.WriteString(fmt.Sprintf(padding+" %d\n", "Other ambiguous:", st.Ambiguous))
+	if st.Gaps > 0 {
+		sb.WriteString(fmt.Sprintf(padding+" %d\n", "Gaps/stops:", st.Gaps))
+	}
+	if st.Unknown > 0 {
+		sb.WriteString(fmt.Sprintf(padding+" %d\n", "Unknown characters:", st.Unknown))
+	}
+	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Molecular weight:", formatMass(st.MW)))
+
+	// composition, most common first
+	letters := make([]byte, 0, len(st.Counts))
+	for c := range st.Counts {
+		letters = append(letters, c)
+	}
+	sort.Slice(letters, func(i, j int) bool {
+		if st.Counts[letters[i]] != st.Counts[letters[j]] {
+			return st.Counts[letters[i]] > st.Counts[letters[j]]
+		}
+		return letters[i] < letters[j]
+	})
+	sb.WriteString("\nComposition:\n")
+	for idx, c := range letters {
+		sb.WriteString(fmt.Sprintf("  %c %8d %6.2f%%", c, st.Counts[c], percent(st.Counts[c], st.Length)))
+		if idx%3 == 2 || idx == len(letters)-1 {
+			sb.WriteString("\n")
+		}
+	}
+
+	if !protein && st.Length > 0 {
+		width = max(width-12, 10)
+		window := max(st.Length/width, 20)
+		gc, skew := GCWindows(seq, window, window)
+		gc, skew = Downsample(gc, width), Downsample(skew, width)
+		sb.WriteString(fmt.Sprintf("\nSliding window of %d bp:\n", window))
+		sb.WriteString(fmt.Sprintf("  %-9s %s\n", "GC 0-100", Sparkline(gc, 0, 100)))
+		sb.WriteString(fmt.Sprintf("  %-9s %s\n", "skew ±1", Sparkline(skew, -1, 1)))
+	}
+	return sb.String()
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
+
+func formatMass(da float64) string {
+	switch {
+	case da >= 1e6:
+		return fmt.Sprintf("%.2f MDa", da/1e6)
+	case da >= 1e3:
+		return fmt.Sprintf("%.2f kDa", da/1e3)
+	}
+	return fmt.Sprintf("%.2f Da", da)
+}
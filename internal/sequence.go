@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IUPAC complements, ambiguity codes included (R=A/G pairs with Y=C/T and so
+// on). Case is kept.
+var complements = func() [256]byte {
+	var t [256]byte
+	for i := range t {
+		t[i] = byte(i)
+	}
+	pairs := []string{"AT", "CG", "RY", "KM", "BV", "DH"}
+	for _, p := range pairs {
+		a, b := p[0], p[1]
+		t[a], t[b] = b, a
+		t[a+32], t[b+32] = b+32, a+32
+	}
+	// U pairs with A; A goes back to T
+	t['U'], t['u'] = 'A', 'a'
+	return t
+}()
+
+// rnaComplements is complements with A going to U.
+var rnaComplements = func() [256]byte {
+	t := complements
+	t['A'], t['a'] = 'U', 'u'
+	return t
+}()
+
+// complementTable picks the RNA table for a sequence with U and no T.
+func complementTable(seq string) *[256]byte {
+	if strings.ContainsAny(seq, "Uu") && !strings.ContainsAny(seq, "Tt") {
+		return &rnaComplements
+	}
+	return &complements
+}
+
+// Complement complements each base, leaving S, W, N and gaps as they are.
+// RNA stays RNA.
+func Complement(seq string) string {
+	t := complementTable(seq)
+	out := make([]byte, len(seq))
+	for i := 0; i < len(seq); i++ {
+		out[i] = t[seq[i]]
+	}
+	return string(out)
+}
+
+// ReverseComplement returns the other strand, read 5' to 3'.
+func ReverseComplement(seq string) string {
+	t := complementTable(seq)
+	out := make([]byte, len(seq))
+	for i := 0; i < len(seq); i++ {
+		out[len(seq)-1-i] = t[seq[i]]
+	}
+	return string(out)
+}
+
+// ExtractIntervals cuts a feature's bases out of the record's sequence, in
+// transcript order, reverse complementing intervals with From > To.
+func ExtractIntervals(seq string, ivs []GBInterval) (string, error) {
+	var sb strings.Builder
+	for _, iv := range ivs {
+		if iv.Accession != "" {
+			return "", fmt.Errorf("location refers to another record, %s", iv.Accession)
+		}
+		from, to := min(iv.From, iv.To), max(iv.From, iv.To)
+		if from < 1 || to > len(seq) {
+			return "", fmt.Errorf("%d..%d is outside the sequence", from, to)
+		}
+		part := seq[from-1 : to]
+		if iv.From > iv.To {
+			part = ReverseComplement(part)
+		}
+		sb.WriteString(part)
+	}
+	return sb.String(), nil
+}
+
+// Selection is a 1-based, inclusive range of a record's sequence, read from
+// the reverse strand when Reverse is set.
+type Selection struct {
+	Start, End int
+	Reverse    bool
+}
+
+// ParseSelection reads "1200-1850" or "1200..1850"; a range given end first
+// selects the reverse strand.
+func ParseSelection(s string, length int) (Selection, error) {
+	s = strings.ReplaceAll(strings.ReplaceAll(strings.TrimSpace(s), ",", ""), "..", "-")
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return Selection{}, fmt.Errorf("expected a range like 1200-1850")
+	}
+	var sel Selection
+	if _, err := fmt.Sscan(from, &sel.Start); err != nil {
+		return sel, fmt.Errorf("bad start %q", from)
+	}
+	if _, err := fmt.Sscan(to, &sel.End); err != nil {
+		return sel, fmt.Errorf("bad end %q", to)
+	}
+	if sel.End < sel.Start {
+		sel.Start, sel.End, sel.Reverse = sel.End, sel.Start, true
+	}
+	if sel.Start < 1 || sel.End > length {
+		return sel, fmt.Errorf("%d-%d is outside the sequence (1-%d)", sel.Start, sel.End, length)
+	}
+	return sel, nil
+}
+
+func (s Selection) Len() int { return s.End - s.Start + 1 }
+
+// Extract cuts the selection out of seq.
+func (s Selection) Extract(seq string) string {
+	sub := seq[s.Start-1 : s.End]
+	if s.Reverse {
+		return ReverseComplement(sub)
+	}
+	return sub
+}
+
+// Record names the selection the way NCBI does for a range of a record,
+// accession:start-end, or accession:cend-start for the reverse strand.
+func (s Selection) Record(acc, description, seq string) FastaRecord {
+	id := fmt.Sprintf("%s:%d-%d", acc, s.Start, s.End)
+	if s.Reverse {
+		id = fmt.Sprintf("%s:c%d-%d", acc, s.End, s.Start)
+	}
+	return FastaRecord{ID: id, Description: description, Sequence: s.Extract(seq)}
+}
@@ -0,0 +1,327 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GeneticCode is an NCBI translation table. AAs and Starts list the 64
+// codons in TCAG order, as in NCBI's gc.prt: an M in Starts marks an
+// initiation codon, and a * marks a codon that can also terminate.
+type GeneticCode struct {
+	ID     int
+	Name   string
+	AAs    string
+	Starts string
+}
+
+var GeneticCodes = []GeneticCode{
+	{1, "Standard", "FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "---M------**--*----M---------------M----------------------------"},
+	{2, "Vertebrate Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSS**VVVVAAAADDEEGGGG", "----------**--------------------MMMM----------**---M------------"},
+	{3, "Yeast Mitochondrial", "FFLLSSSSYY**CCWWTTTTPPPPHHQQRRRRIIMMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------**----------------------MM---------------M------------"},
+	{4, "Mold, Protozoan, and Coelenterate Mitochondrial and the Mycoplasma/Spiroplasma", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--MM------**-------M------------MMMM---------------M------------"},
+	{5, "Invertebrate Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSSSVVVVAAAADDEEGGGG", "---M------**--------------------MMMM---------------M------------"},
+	{6, "Ciliate, Dasycladacean and Hexamita Nuclear", "FFLLSSSSYYQQCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--------------*--------------------M----------------------------"},
+	{9, "Echinoderm and Flatworm Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG", "----------**-----------------------M---------------M------------"},
+	{10, "Euplotid Nuclear", "FFLLSSSSYY**CCCWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------**-----------------------M----------------------------"},
+	{11, "Bacterial, Archaeal and Plant Plastid", "FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "---M------**--*----M------------MMMM---------------M------------"},
+	{12, "Alternative Yeast Nuclear", "FFLLSSSSYY**CC*WLLLSPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------**--*----M---------------M----------------------------"},
+	{13, "Ascidian Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSGGVVVVAAAADDEEGGGG", "---M------**----------------------MM---------------M------------"},
+	{14, "Alternative Flatworm Mitochondrial", "FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG", "-----------*-----------------------M----------------------------"},
+	{15, "Blepharisma Nuclear", "FFLLSSSSYY*QCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------*---*--------------------M----------------------------"},
+	{16, "Chlorophycean Mitochondrial", "FFLLSSSSYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------*---*--------------------M----------------------------"},
+	{21, "Trematode Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNNKSSSSVVVVAAAADDEEGGGG", "----------**-----------------------M---------------M------------"},
+	{22, "Scenedesmus obliquus Mitochondrial", "FFLLSS*SYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "------*---*---*--------------------M----------------------------"},
+	{23, "Thraustochytrium Mitochondrial", "FF*LSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--*-------**--*-----------------M--M---------------M------------"},
+	{24, "Rhabdopleuridae Mitochondrial", "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG", "---M------**-------M---------------M---------------M------------"},
+	{25, "Candidate Division SR1 and Gracilibacteria", "FFLLSSSSYY**CCGWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "---M------**-----------------------M---------------M------------"},
+	{26, "Pachysolen tannophilus Nuclear", "FFLLSSSSYY**CC*WLLLAPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------**--*----M---------------M----------------------------"},
+	{27, "Karyorelict Nuclear", "FFLLSSSSYYQQCCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--------------*--------------------M----------------------------"},
+	{28, "Condylostoma Nuclear", "FFLLSSSSYYQQCCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------**--*--------------------M----------------------------"},
+	{29, "Mesodinium Nuclear", "FFLLSSSSYYYYCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--------------*--------------------M----------------------------"},
+	{30, "Peritrich Nuclear", "FFLLSSSSYYEECC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "--------------*--------------------M----------------------------"},
+	{31, "Blastocrithidia Nuclear", "FFLLSSSSYYEECCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "----------**-----------------------M----------------------------"},
+	{32, "Balanophoraceae Plastid", "FFLLSSSSYY*WCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG", "---M------*---*----M------------MMMM---------------M------------"},
+	{33, "Cephalodiscidae Mitochondrial", "FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG", "---M-------*-------M---------------M---------------M------------"},
+}
+
+func FindGeneticCode(id int) (GeneticCode, error) {
+	for _, gc := range GeneticCodes {
+		if gc.ID == id {
+			return gc, nil
+		}
+	}
+	return GeneticCode{}, fmt.Errorf("no genetic code %d", id)
+}
+
+func (gc GeneticCode) String() string {
+	return fmt.Sprintf("%d (%s)", gc.ID, gc.Name)
+}
+
+var baseIndex = map[byte]int{'T': 0, 'U': 0, 'C': 1, 'A': 2, 'G': 3}
+
+// codonIndexes lists the table positions a codon could be, more than one
+// when it has ambiguity codes. It returns nil for anything untranslatable.
+func codonIndexes(codon string) []int {
+	if len(codon) != 3 {
+		return nil
+	}
+	idx := []int{0}
+	for i := 0; i < 3; i++ {
+		options := iupacBases[upper(codon[i])]
+		if options == "" {
+			return nil
+		}
+		var next []int
+		for _, n := range idx {
+			for j := 0; j < len(options); j++ {
+				next = append(next, n*4+baseIndex[options[j]])
+			}
+		}
+		idx = next
+	}
+	return idx
+}
+
+// Amino translates one codon. An ambiguous codon translates when every
+// reading of it agrees (GGN is G); otherwise it is X.
+func (gc GeneticCode) Amino(codon string) byte {
+	idx := codonIndexes(codon)
+	if idx == nil {
+		return 'X'
+	}
+	aa := gc.AAs[idx[0]]
+	for _, i := range idx[1:] {
+		if gc.AAs[i] != aa {
+			return 'X'
+		}
+	}
+	return aa
+}
+
+// IsStart reports whether codon is an initiation codon, ambiguity codes
+// allowed as long as every reading is one.
+func (gc GeneticCode) IsStart(codon string) bool {
+	return gc.all(codon, func(i int) bool { return gc.Starts[i] == 'M' })
+}
+
+// IsStop reports whether codon always terminates. Codons that only stop in
+// context, as in tables 27, 28 and 31, translate as their amino acid.
+func (gc GeneticCode) IsStop(codon string) bool {
+	return gc.all(codon, func(i int) bool { return gc.AAs[i] == '*' })
+}
+
+func (gc GeneticCode) all(codon string, test func(int) bool) bool {
+	idx := codonIndexes(codon)
+	if idx == nil {
+		return false
+	}
+	for _, i := range idx {
+		if !test(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// StartCodons lists the unambiguous initiation codons.
+func (gc GeneticCode) StartCodons() []string {
+	var out []string
+	for i := 0; i < 64; i++ {
+		if gc.Starts[i] == 'M' {
+			out = append(out, codonName(i))
+		}
+	}
+	return out
+}
+
+type TranslateOptions struct {
+	// CDS translates a coding sequence: the first codon becomes M when it
+	// is any initiation codon, and a final codon that can terminate is
+	// dropped.
+	CDS bool
+	// ToStop ends the protein at the first stop codon instead of writing *.
+	ToStop bool
+}
+
+// Translate reads seq in frame from its first base. Trailing bases that
+// don't make a codon are ignored.
+func (gc GeneticCode) Translate(seq string, opts TranslateOptions) string {
+	var sb strings.Builder
+	n := len(seq) / 3
+	for i := 0; i < n; i++ {
+		codon := seq[i*3 : i*3+3]
+		aa := gc.Amino(codon)
+		switch {
+		case opts.CDS && i == 0 && gc.IsStart(codon):
+			aa = 'M'
+		case opts.CDS && i == n-1 && gc.all(codon, func(i int) bool { return gc.AAs[i] == '*' || gc.Starts[i] == '*' }):
+			return sb.String()
+		}
+		if aa == '*' && opts.ToStop {
+			break
+		}
+		sb.WriteByte(aa)
+	}
+	return sb.String()
+}
+
+// Frame translates one of the six reading frames: 1, 2, 3 on the given
+// strand, -1, -2, -3 on the reverse complement.
+func (gc GeneticCode) Frame(seq string, frame int) string {
+	if frame < 0 {
+		seq = ReverseComplement(seq)
+		frame = -frame
+	}
+	if frame > len(seq) {
+		return ""
+	}
+	return gc.Translate(seq[frame-1:], TranslateOptions{})
+}
+
+// RecordGeneticCode picks the code for a record: the first CDS's
+// /transl_table if there is one, otherwise the default for the organism.
+func RecordGeneticCode(seq GBSeq) GeneticCode {
+	for _, f := range seq.Features {
+		if f.Key != "CDS" {
+			continue
+		}
+		if v, ok := f.Qualifier("transl_table"); ok {
+			var id int
+			fmt.Sscan(v, &id)
+			if gc, err := FindGeneticCode(id); err == nil {
+				return gc
+			}
+		}
+	}
+	gc, _ := FindGeneticCode(DefaultGeneticCode(seq))
+	return gc
+}
+
+// DefaultGeneticCode guesses the genetic code from the taxonomy and, for
+// organelle sequences, the source feature's /organelle. It covers the common
+// lineages; anything else gets the standard code.
+func DefaultGeneticCode(seq GBSeq) int {
+	tax := seq.Taxonomy + "; " + seq.Organism
+	has := func(names ...string) bool {
+		for _, n := range names {
+			if strings.Contains(tax, n) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var organelle string
+	for _, f := range seq.Features {
+		if f.Key == "source" {
+			organelle, _ = f.Qualifier("organelle")
+			break
+		}
+	}
+	switch {
+	case strings.Contains(organelle, "mitochondrion"):
+		switch {
+		case has("Vertebrata"):
+			return 2
+		case has("Ascidiacea"):
+			return 13
+		case has("Echinodermata", "Platyhelminthes"):
+			return 9
+		case has("Saccharomycetes", "Saccharomycetaceae"):
+			return 3
+		case has("Fungi", "Cnidaria", "Ciliophora", "Euglenozoa"):
+			return 4
+		case has("Arthropoda", "Mollusca", "Nematoda", "Annelida"):
+			return 5
+		}
+		return 1
+	case strings.Contains(organelle, "plastid") || strings.Contains(organelle, "chloroplast"):
+		return 11
+	case has("Mycoplasma", "Spiroplasma", "Mycoplasmoides"):
+		return 4
+	case has("Bacteria", "Archaea"):
+		return 11
+	case has("Ciliophora"):
+		return 6
+	}
+	return 1
+}
+
+// FrameViewLimit caps how much of a sequence the six-frame view lays out.
+var FrameViewLimit = 250_000
+
+var (
+	stopStyle  = errorStyle
+	startStyle = doneStyle
+)
+
+// SixFrameView lays out the sequence in numbered blocks with the three
+// forward frames above, the complement and the three reverse frames below.
+// Each amino acid sits under the middle base of its codon; starts are green
+// and stops red.
+func SixFrameView(seq string, gc GeneticCode, width int) string {
+	var sb strings.Builder
+	if len(seq) > FrameViewLimit {
+		sb.WriteString(faintStyle.Render(fmt.Sprintf("showing the first %d of %d bases", FrameViewLimit, len(seq))) + "\n\n")
+		seq = seq[:FrameViewLimit]
+	}
+	block := 60
+	if width < 75 {
+		block = 30
+	}
+
+	// one line per frame, as long as the sequence, with amino acids placed
+	// under their codons
+	rc := ReverseComplement(seq)
+	frames := map[int][]byte{}
+	starts := map[int][]bool{}
+	for _, f := range []int{1, 2, 3, -1, -2, -3} {
+		line := []byte(strings.Repeat(" ", len(seq)))
+		isStart := make([]bool, len(seq))
+		strand, from := seq, f-1
+		if f < 0 {
+			strand, from = rc, -f-1
+		}
+		for p := from; p+3 <= len(strand); p += 3 {
+			codon := strand[p : p+3]
+			mid := p + 1
+			if f < 0 {
+				mid = len(seq) - 2 - p
+			}
+			line[mid] = gc.Amino(codon)
+			isStart[mid] = gc.IsStart(codon)
+		}
+		frames[f], starts[f] = line, isStart
+	}
+
+	render := func(f, from, to int) string {
+		var line strings.Builder
+		for i := from; i < to; i++ {
+			c := string(frames[f][i])
+			switch {
+			case frames[f][i] == '*':
+				c = stopStyle.Render(c)
+			case starts[f][i]:
+				c = startStyle.Render(c)
+			}
+			line.WriteString(c)
+		}
+		return fmt.Sprintf("%9s %s\n", fmt.Sprintf("%+d", f), line.String())
+	}
+
+	comp := Complement(seq)
+	for from := 0; from < len(seq); from += block {
+		to := min(from+block, len(seq))
+		for _, f := range []int{3, 2, 1} {
+			sb.WriteString(render(f, from, to))
+		}
+		sb.WriteString(fmt.Sprintf("%9d %s\n", from+1, seq[from:to]))
+		sb.WriteString(fmt.Sprintf("%9s %s\n", "", faintStyle.Render(comp[from:to])))
+		for _, f := range []int{-1, -2, -3} {
+			sb.WriteString(render(f, from, to))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
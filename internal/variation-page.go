@@ -0,0 +1,321 @@
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// variants kept in memory per query; narrow the filters to see the rest
+var VariantLimit = 10000
+
+type variantItem struct {
+	rec VCFRecord
+}
+
+func (i variantItem) Title() string {
+	alt := strings.Join(i.rec.Alt, ",")
+	if alt == "" {
+		alt = "."
+	}
+	return fmt.Sprintf("%s:%d %s>%s", i.rec.Chrom, i.rec.Pos, i.rec.Ref, alt)
+}
+
+func (i variantItem) Description() string {
+	id := strings.Join(i.rec.IDs, ";")
+	if id == "" {
+		id = "."
+	}
+	filter := strings.Join(i.rec.Filter, ";")
+	if filter == "" {
+		filter = "."
+	}
+	return fmt.Sprintf("%s - QUAL %s - %s", id, i.rec.Qual, filter)
+}
+
+func (i variantItem) FilterValue() string { return i.Title() + " " + strings.Join(i.rec.IDs, " ") }
+
+type variationPage struct {
+	Title    string
+	Input    textinput.Model
+	Path     string
+	Header   VCFHeader
+	Matched  int
+	Results  list.Model
+	Spinner  spinner.Model
+	Loading  bool
+	Received bool
+	Err      error
+
+	// filters
+	Region   textinput.Model
+	InfoExpr textinput.Model
+	PassOnly bool
+	Editing  *textinput.Model
+
+	// genotype view for the selected variant
+	Genotypes *viewport.Model
+}
+
+func NewVariationPage() *variationPage {
+	ti := textinput.New()
+	ti.Placeholder = "path/to/variants.vcf.gz"
+	ti.Focus()
+	ti.CharLimit = 512
+	ti.Width = 50
+
+	region := textinput.New()
+	region.Prompt = "Region: "
+	region.Placeholder = "chr1:10000-20000"
+	region.Width = 30
+
+	info := textinput.New()
+	info.Prompt = "INFO:   "
+	info.Placeholder = "DP>10,AF<0.05,DB"
+	info.Width = 30
+
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+
+	return &variationPage{
+		Title:    "Variation",
+		Input:    ti,
+		Region:   region,
+		InfoExpr: info,
+		Spinner:  s,
+	}
+}
+
+type vcfMsg struct {
+	path    string
+	header  VCFHeader
+	records []VCFRecord
+	matched int
+}
+
+func (page *variationPage) filter() (VCFFilter, error) {
+	f := VCFFilter{PassOnly: page.PassOnly}
+	if v := strings.TrimSpace(page.Region.Value()); v != "" {
+		r, err := ParseRegion(v)
+		if err != nil {
+			return f, err
+		}
+		f.Region = &r
+	}
+	conds, err := ParseInfoConditions(page.InfoExpr.Value())
+	if err != nil {
+		return f, err
+	}
+	f.Info = conds
+	return f, nil
+}
+
+func (page *variationPage) load(path string) tea.Cmd {
+	filter, err := page.filter()
+	if err != nil {
+		page.Err = err
+		return nil
+	}
+	page.Loading = true
+	page.Err = nil
+	return tea.Batch(page.Spinner.Tick, func() tea.Msg {
+		header, recs, matched, err := ReadVCFFile(path, filter, VariantLimit)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return vcfMsg{path: path, header: header, records: recs, matched: matched}
+	})
+}
+
+// UpdatePage implements page.
+func (page *variationPage) UpdatePage(msg tea.Msg, m Model) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		// editing a filter
+		if page.Editing != nil {
+			switch msg.String() {
+			case "enter":
+				page.Editing.Blur()
+				page.Editing = nil
+				return m, page.load(page.Path)
+			}
+			var cmd tea.Cmd
+			*page.Editing, cmd = page.Editing.Update(msg)
+			return m, cmd
+		}
+
+		// genotype view
+		if page.Genotypes != nil {
+			if key.Matches(msg, m.Keys.Back) {
+				page.Genotypes = nil
+				return m, nil
+			}
+			var cmd tea.Cmd
+			*page.Genotypes, cmd = page.Genotypes.Update(msg)
+			return m, cmd
+		}
+
+		if page.Received && page.Results.FilterState() != list.Filtering {
+			switch msg.String() {
+			case "r":
+				page.Editing = &page.Region
+				return m, page.Region.Focus()
+			case "i":
+				page.Editing = &page.InfoExpr
+				return m, page.InfoExpr.Focus()
+			case "p":
+				page.PassOnly = !page.PassOnly
+				return m, page.load(page.Path)
+			}
+		}
+
+		switch {
+		case key.Matches(msg, m.Keys.Enter):
+			if !page.Received && !page.Loading {
+				return m, page.load(page.Input.Value())
+			}
+		case key.Matches(msg, m.Keys.Back):
+			if page.Input.Value() == "" && !page.Received {
+				m.UpdateBack(msg)
+			}
+			if page.Received && page.Results.FilterState() == list.Unfiltered {
+				page.Received = false
+				page.Input.Focus()
+				return m, nil
+			}
+		}
+
+	case vcfMsg:
+		page.Loading = false
+		page.Path = msg.path
+		page.Header = msg.header
+		page.Matched = msg.matched
+
+		items := make([]list.Item, len(msg.records))
+		for idx, rec := range msg.records {
+			items[idx] = variantItem{rec: rec}
+		}
+		d := list.NewDefaultDelegate()
+		d.UpdateFunc = UpdateDelegate
+		page.Results = list.New(items, d, m.Width-20, m.Height-14)
+		page.Results.Title = page.resultsTitle()
+		m.ShowHelp = false
+		page.Received = true
+
+	case listSelectMsg:
+		if item, ok := page.Results.SelectedItem().(variantItem); ok {
+			v := viewport.New(m.Width-20, m.Height-10)
+			v.SetContent(VariantReport(page.Header, item.rec))
+			page.Genotypes = &v
+		}
+		return m, nil
+
+	case errMsg:
+		page.Loading = false
+		page.Err = msg.err
+
+	case tea.WindowSizeMsg:
+		if page.Received {
+			page.Results.SetSize(msg.Width-20, msg.Height-14)
+		}
+	}
+
+	var cmd tea.Cmd
+	if page.Loading {
+		page.Spinner, cmd = page.Spinner.Update(msg)
+	} else if page.Received {
+		page.Results, cmd = page.Results.Update(msg)
+	} else {
+		page.Input, cmd = page.Input.Update(msg)
+	}
+	return m, cmd
+}
+
+func (page *variationPage) resultsTitle() string {
+	title := fmt.Sprintf("%s: %d variants", filepath.Base(page.Path), page.Matched)
+	if page.Matched > VariantLimit {
+		title += fmt.Sprintf(" (showing first %d)", VariantLimit)
+	}
+	return title
+}
+
+// VariantReport describes one variant: its INFO fields with their header
+// descriptions, then a row per sample with the genotype and FORMAT values.
+func VariantReport(h VCFHeader, rec VCFRecord) string {
+	var sb strings.Builder
+	padding := fmt.Sprintf("%%-%ds", LabelPadding)
+
+	sb.WriteString("=== VARIANT ===\n")
+	sb.WriteString(fmt.Sprintf(padding+" %s:%d\n", "Position:", rec.Chrom, rec.Pos))
+	sb.WriteString(fmt.Sprintf(padding+" %s\n", "ID:", strings.Join(rec.IDs, ";")))
+	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Ref:", rec.Ref))
+	for idx, alt := range rec.Alt {
+		sb.WriteString(fmt.Sprintf(padding+" %s\n", fmt.Sprintf("Alt %d:", idx+1), alt))
+	}
+	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Qual:", rec.Qual))
+	sb.WriteString(fmt.Sprintf(padding+" %s\n", "Filter:", strings.Join(rec.Filter, ";")))
+
+	if len(rec.Info) > 0 {
+		sb.WriteString("\n--- INFO ---\n")
+		for _, kv := range rec.Info {
+			line := kv.Key
+			if kv.Value != "" {
+				line += "=" + kv.Value
+			}
+			sb.WriteString(fmt.Sprintf("  %-30s %s\n", line, h.Info[kv.Key].Description))
+		}
+	}
+
+	if len(h.Samples) > 0 {
+		sb.WriteString("\n--- GENOTYPES ---\n")
+		sb.WriteString(fmt.Sprintf("  %-20s %-12s %s\n", "Sample", "Genotype", strings.Join(rec.Format, ":")))
+		for idx, name := range h.Samples {
+			values := "."
+			if idx < len(rec.Samples) {
+				values = strings.Join(rec.Samples[idx], ":")
+			}
+			sb.WriteString(fmt.Sprintf("  %-20s %-12s %s\n", name, rec.Genotype(idx), values))
+		}
+	}
+	return sb.String()
+}
+
+// Page implements page.
+func (page *variationPage) Page(m Model) string {
+	p := "Open a local VCF file (plain or bgzipped) and browse its variants.\n\n"
+
+	switch {
+	case page.Loading:
+		p += page.Spinner.View() + " Reading variants ... "
+	case page.Genotypes != nil:
+		p += page.Genotypes.View()
+	case page.Received:
+		pass := "all"
+		if page.PassOnly {
+			pass = "PASS only"
+		}
+		p += page.Region.View() + "\n" + page.InfoExpr.View() + "\n"
+		p += fmt.Sprintf("Filter: %s\n", pass)
+		p += faintStyle.Render("r: region • i: INFO conditions • p: toggle PASS only • enter: genotypes") + "\n\n"
+		p += page.Results.View()
+	default:
+		p += page.Input.View()
+	}
+	if page.Err != nil {
+		p += "\n\n" + errorStyle.Render(page.Err.Error())
+	}
+	return p + "\n\n"
+}
+
+func (page *variationPage) GetTitle() string {
+	return page.Title
+}
@@ -0,0 +1,430 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// VCFFieldDef is an INFO or FORMAT definition from the header.
+type VCFFieldDef struct {
+	ID          string
+	Number      string
+	Type        string
+	Description string
+}
+
+type VCFHeader struct {
+	FileFormat string
+	Meta       []string // every ## line, as written
+	Info       map[string]VCFFieldDef
+	InfoOrder  []string
+	Format     map[string]VCFFieldDef
+	Filters    map[string]string
+	Contigs    []string
+	Samples    []string
+}
+
+type VCFInfo struct {
+	Key   string
+	Value string // empty for flags
+}
+
+type VCFRecord struct {
+	Chrom  string
+	Pos    int
+	IDs    []string
+	Ref    string
+	Alt    []string // one entry per allele in multi-allelic records
+	Qual   string
+	Filter []string
+	Info   []VCFInfo
+	Format []string
+	// Samples holds each sample's FORMAT values, in header order
+	Samples [][]string
+}
+
+func (rec VCFRecord) InfoValue(key string) (string, bool) {
+	for _, kv := range rec.Info {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return "", false
+}
+
+// SampleValue returns one FORMAT field for a sample, "." when it is missing.
+func (rec VCFRecord) SampleValue(sample int, key string) string {
+	if sample >= len(rec.Samples) {
+		return "."
+	}
+	for idx, k := range rec.Format {
+		if k == key && idx < len(rec.Samples[sample]) {
+			return rec.Samples[sample][idx]
+		}
+	}
+	return "."
+}
+
+// Passed reports whether the record passed all filters.
+func (rec VCFRecord) Passed() bool {
+	return len(rec.Filter) == 1 && rec.Filter[0] == "PASS"
+}
+
+// Genotype turns a GT value like "0|1" into allele strings, "A|G".
+func (rec VCFRecord) Genotype(sample int) string {
+	gt := rec.SampleValue(sample, "GT")
+	if gt == "." {
+		return gt
+	}
+	alleles := append([]string{rec.Ref}, rec.Alt...)
+	var sb strings.Builder
+	start := 0
+	for idx := 0; idx <= len(gt); idx++ {
+		if idx < len(gt) && gt[idx] != '/' && gt[idx] != '|' {
+			continue
+		}
+		n, err := strconv.Atoi(gt[start:idx])
+		if err != nil || n >= len(alleles) {
+			sb.WriteString(gt[start:idx])
+		} else {
+			sb.WriteString(alleles[n])
+		}
+		if idx < len(gt) {
+			sb.WriteByte(gt[idx])
+		}
+		start = idx + 1
+	}
+	return sb.String()
+}
+
+type VCFReader struct {
+	Header VCFHeader
+	s      *bufio.Scanner
+	line   int
+}
+
+// NewVCFReader reads the header and leaves the reader at the first record.
+func NewVCFReader(r io.Reader) (*VCFReader, error) {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 1<<16), 1<<26)
+	vr := &VCFReader{
+		s: s,
+		Header: VCFHeader{
+			Info:    map[string]VCFFieldDef{},
+			Format:  map[string]VCFFieldDef{},
+			Filters: map[string]string{},
+		},
+	}
+
+	for s.Scan() {
+		vr.line++
+		text := strings.TrimRight(s.Text(), "\r")
+		if strings.HasPrefix(text, "##") {
+			vr.Header.addMeta(text)
+			continue
+		}
+		if strings.HasPrefix(text, "#CHROM") {
+			cols := strings.Split(text, "\t")
+			if len(cols) > 9 {
+				vr.Header.Samples = cols[9:]
+			}
+			return vr, nil
+		}
+		return nil, fmt.Errorf("line %d: expected the #CHROM header line", vr.line)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("no #CHROM header line")
+}
+
+func (h *VCFHeader) addMeta(text string) {
+	h.Meta = append(h.Meta, text)
+	key, value, _ := strings.Cut(text[2:], "=")
+	if key == "fileformat" {
+		h.FileFormat = value
+		return
+	}
+	if !strings.HasPrefix(value, "<") {
+		return
+	}
+	fields := parseVCFStructured(value)
+	switch key {
+	case "INFO":
+		def := VCFFieldDef{ID: fields["ID"], Number: fields["Number"], Type: fields["Type"], Description: fields["Description"]}
+		h.Info[def.ID] = def
+		h.InfoOrder = append(h.InfoOrder, def.ID)
+	case "FORMAT":
+		def := VCFFieldDef{ID: fields["ID"], Number: fields["Number"], Type: fields["Type"], Description: fields["Description"]}
+		h.Format[def.ID] = def
+	case "FILTER":
+		h.Filters[fields["ID"]] = fields["Description"]
+	case "contig":
+		h.Contigs = append(h.Contigs, fields["ID"])
+	}
+}
+
+// parseVCFStructured reads <ID=DP,Number=1,Description="Depth, total">.
+func parseVCFStructured(value string) map[string]string {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "<"), ">")
+	out := map[string]string{}
+	for len(value) > 0 {
+		key, rest, _ := strings.Cut(value, "=")
+		var v string
+		if strings.HasPrefix(rest, `"`) {
+			end := 1
+			for end < len(rest) && (rest[end] != '"' || rest[end-1] == '\\') {
+				end++
+			}
+			v = strings.ReplaceAll(rest[1:min(end, len(rest))], `\"`, `"`)
+			rest = rest[min(end+1, len(rest)):]
+		} else {
+			v, rest, _ = strings.Cut(rest, ",")
+			rest = "," + rest
+		}
+		out[strings.TrimSpace(key)] = v
+		value = strings.TrimPrefix(rest, ",")
+	}
+	return out
+}
+
+// Read returns the next record, or io.EOF when there are none left.
+func (vr *VCFReader) Read() (VCFRecord, error) {
+	var rec VCFRecord
+	for vr.s.Scan() {
+		vr.line++
+		text := strings.TrimRight(vr.s.Text(), "\r")
+		if text == "" {
+			continue
+		}
+		cols := strings.Split(text, "\t")
+		if len(cols) < 8 {
+			return rec, fmt.Errorf("line %d: expected at least 8 columns, got %d", vr.line, len(cols))
+		}
+		pos, err := strconv.Atoi(cols[1])
+		if err != nil {
+			return rec, fmt.Errorf("line %d: bad position %q", vr.line, cols[1])
+		}
+		rec = VCFRecord{
+			Chrom:  cols[0],
+			Pos:    pos,
+			IDs:    splitVCFList(cols[2], ";"),
+			Ref:    cols[3],
+			Alt:    splitVCFList(cols[4], ","),
+			Qual:   cols[5],
+			Filter: splitVCFList(cols[6], ";"),
+		}
+		if cols[7] != "." {
+			for _, kv := range strings.Split(cols[7], ";") {
+				k, v, _ := strings.Cut(kv, "=")
+				rec.Info = append(rec.Info, VCFInfo{Key: k, Value: v})
+			}
+		}
+		if len(cols) > 8 {
+			rec.Format = strings.Split(cols[8], ":")
+			for _, sample := range cols[9:] {
+				rec.Samples = append(rec.Samples, strings.Split(sample, ":"))
+			}
+		}
+		return rec, nil
+	}
+	if err := vr.s.Err(); err != nil {
+		return rec, err
+	}
+	return rec, io.EOF
+}
+
+func splitVCFList(s, sep string) []string {
+	if s == "." || s == "" {
+		return nil
+	}
+	return strings.Split(s, sep)
+}
+
+// Region is a 1-based, inclusive genomic interval. End 0 means to the end
+// of the sequence, and Start 0 from its beginning.
+type Region struct {
+	Chrom string
+	Start int
+	End   int
+}
+
+// ParseRegion reads "chr1", "chr1:1,000-2,000" or, like samtools, "chr1:1000"
+// for everything from 1000 on. The name ends at the last ':', so it may
+// contain colons itself.
+func ParseRegion(s string) (Region, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	i := strings.LastIndex(s, ":")
+	if i < 0 {
+		return Region{Chrom: s}, nil
+	}
+	r := Region{Chrom: s[:i]}
+	from, to, _ := strings.Cut(s[i+1:], "-")
+	var err error
+	if r.Start, err = strconv.Atoi(from); err != nil {
+		return r, fmt.Errorf("bad region start %q", from)
+	}
+	if to != "" {
+		if r.End, err = strconv.Atoi(to); err != nil {
+			return r, fmt.Errorf("bad region end %q", to)
+		}
+		if r.End < r.Start {
+			return r, fmt.Errorf("region end %d is before start %d", r.End, r.Start)
+		}
+	}
+	return r, nil
+}
+
+func (r Region) String() string {
+	switch {
+	case r.Start == 0 && r.End == 0:
+		return r.Chrom
+	case r.End == 0:
+		return fmt.Sprintf("%s:%d-", r.Chrom, r.Start)
+	}
+	return fmt.Sprintf("%s:%d-%d", r.Chrom, r.Start, r.End)
+}
+
+// Overlaps reports whether [start, end] on chrom overlaps the region.
+func (r Region) Overlaps(chrom string, start, end int) bool {
+	if chrom != r.Chrom {
+		return false
+	}
+	return (r.End == 0 || start <= r.End) && end >= r.Start
+}
+
+// VCFFilter selects records by region, FILTER status and INFO conditions.
+type VCFFilter struct {
+	Region   *Region
+	PassOnly bool
+	Info     []InfoCondition
+}
+
+// InfoCondition is one test such as DP>10, AF<=0.05, SVTYPE=DEL or a bare
+// flag name like DB.
+type InfoCondition struct {
+	Key   string
+	Op    string // "", "=", "!=", "<", "<=", ">", ">="
+	Value string
+}
+
+// ParseInfoConditions reads a comma separated list of conditions.
+func ParseInfoConditions(s string) ([]InfoCondition, error) {
+	var out []InfoCondition
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		cond := InfoCondition{Key: part}
+		for _, op := range []string{"!=", "<=", ">=", "=", "<", ">"} {
+			if k, v, ok := strings.Cut(part, op); ok {
+				cond = InfoCondition{Key: strings.TrimSpace(k), Op: op, Value: strings.TrimSpace(v)}
+				break
+			}
+		}
+		if cond.Key == "" {
+			return nil, fmt.Errorf("bad INFO condition %q", part)
+		}
+		out = append(out, cond)
+	}
+	return out, nil
+}
+
+// Match tests the condition against a record. Per-allele values pass if any
+// allele passes.
+func (c InfoCondition) Match(rec VCFRecord) bool {
+	value, ok := rec.InfoValue(c.Key)
+	if !ok {
+		return c.Op == "!="
+	}
+	if c.Op == "" {
+		return true
+	}
+	for _, v := range strings.Split(value, ",") {
+		if compareVCFValue(v, c.Op, c.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+func compareVCFValue(v, op, want string) bool {
+	a, errA := strconv.ParseFloat(v, 64)
+	b, errB := strconv.ParseFloat(want, 64)
+	if errA != nil || errB != nil {
+		switch op {
+		case "=":
+			return v == want
+		case "!=":
+			return v != want
+		}
+		return false
+	}
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+func (f VCFFilter) Match(rec VCFRecord) bool {
+	if f.PassOnly && !rec.Passed() {
+		return false
+	}
+	if f.Region != nil && !f.Region.Overlaps(rec.Chrom, rec.Pos, rec.Pos+max(len(rec.Ref), 1)-1) {
+		return false
+	}
+	for _, c := range f.Info {
+		if !c.Match(rec) {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadVCFFile reads a plain or bgzipped VCF, keeping at most limit records
+// that pass the filter. It also reports how many records matched in total.
+func ReadVCFFile(path string, filter VCFFilter, limit int) (VCFHeader, []VCFRecord, int, error) {
+	f, err := OpenFile(path)
+	if err != nil {
+		return VCFHeader{}, nil, 0, err
+	}
+	defer f.Close()
+
+	vr, err := NewVCFReader(f)
+	if err != nil {
+		return VCFHeader{}, nil, 0, fmt.Errorf("%s: %v", path, err)
+	}
+	var recs []VCFRecord
+	matched := 0
+	for {
+		rec, err := vr.Read()
+		if err == io.EOF {
+			return vr.Header, recs, matched, nil
+		}
+		if err != nil {
+			return vr.Header, nil, 0, fmt.Errorf("%s: %v", path, err)
+		}
+		if !filter.Match(rec) {
+			continue
+		}
+		matched++
+		if len(recs) < limit {
+			recs = append(recs, rec)
+		}
+	}
+}